@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// apiTokenEnv holds the API token, taking precedence over the token file
+const apiTokenEnv = "CLEARANCE_API_TOKEN"
+
+// apiToken returns the token API requests must carry: the environment
+// variable, or the contents of path, generated on first use
+func apiToken(path string) (token, source string, err error) {
+	if token := strings.TrimSpace(os.Getenv(apiTokenEnv)); token != "" {
+		return token, apiTokenEnv, nil
+	}
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", "", fmt.Errorf("cannot locate config directory: %w", err)
+		}
+		path = filepath.Join(dir, "clearance", "api.token")
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, path, nil
+		}
+		return "", "", fmt.Errorf("token file %s is empty", path)
+	}
+	if !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", "", err
+	}
+	// Only the owner may read the token
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", "", err
+	}
+	return token, path, nil
+}
+
+// apiCleaner is a selectable cleaner in the cleaners response
+type apiCleaner struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Part        string `json:"part,omitempty"`
+	InAll       bool   `json:"in_all"`
+	Available   bool   `json:"available"`
+	// Status is why the cleaner cannot run here
+	Status string `json:"status,omitempty"`
+}
+
+// apiSize is the size of one cache in the sizes response
+type apiSize struct {
+	Cleaner string `json:"cleaner"`
+	Label   string `json:"label"`
+	Part    string `json:"part,omitempty"`
+	// Bytes is -1 if the cache could not be measured, see Status
+	Bytes  int64   `json:"bytes"`
+	Status string  `json:"status"`
+	Volume string  `json:"volume,omitempty"`
+	Share  float64 `json:"share_percent"`
+}
+
+// apiVolume is a file system holding caches in the sizes response
+type apiVolume struct {
+	Path  string `json:"path"`
+	Total int64  `json:"total_bytes"`
+	Free  int64  `json:"free_bytes"`
+}
+
+// apiSizes is the sizes response
+type apiSizes struct {
+	GeneratedAt time.Time   `json:"generated_at"`
+	Total       int64       `json:"total_bytes"`
+	Caches      []apiSize   `json:"caches"`
+	Volumes     []apiVolume `json:"volumes"`
+}
+
+// apiPlanItem is a target a cleaner would remove or keep
+type apiPlanItem struct {
+	Target string `json:"target"`
+	// Size is -1 if unknown
+	Size   int64  `json:"size"`
+	Remove bool   `json:"remove"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// apiPlan is one cleaner's plan in the plan response
+type apiPlan struct {
+	Cleaner     string        `json:"cleaner"`
+	Reclaimable int64         `json:"reclaimable_bytes"`
+	Items       []apiPlanItem `json:"items"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// apiResult is one cleaner's outcome in the clean response
+type apiResult struct {
+	Cleaner     string  `json:"cleaner"`
+	BytesBefore int64   `json:"bytes_before"`
+	BytesAfter  int64   `json:"bytes_after"`
+	Freed       int64   `json:"freed"`
+	Duration    float64 `json:"duration_seconds"`
+	Skipped     string  `json:"skipped,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// apiClean is the clean response
+type apiClean struct {
+	RunID string `json:"run_id"`
+	Freed int64  `json:"freed"`
+	// Cancelled is set when the server shut down before every cleaner ran;
+	// Results holds those that did
+	Cancelled bool        `json:"cancelled,omitempty"`
+	Results   []apiResult `json:"results"`
+}
+
+// apiSelection is the body of plan and clean requests. No cleaners selects
+// the ones "all" selects.
+type apiSelection struct {
+	Cleaners []string `json:"cleaners"`
+}
+
+// apiServer serves the JSON API over the registry
+type apiServer struct {
+	// ctx outlives requests, so a clean is not abandoned halfway when the
+	// client disconnects
+	ctx      context.Context
+	u        *ui.UI
+	registry *clearance.Registry
+	token    string
+}
+
+// handler returns the API routes behind the token check
+func (a *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/cleaners", a.cleaners)
+	mux.HandleFunc("GET /api/v1/sizes", a.sizes)
+	mux.HandleFunc("POST /api/v1/plan", a.plan)
+	mux.HandleFunc("POST /api/v1/clean", a.clean)
+	return a.authorize(mux)
+}
+
+// authorize rejects requests without the bearer token
+func (a *apiServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="clearance"`)
+			writeAPIError(w, http.StatusUnauthorized, stderrors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON writes v as the response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError writes err as {"error": "..."}
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// selection reads the cleaners a plan or clean request selects
+func (a *apiServer) selection(w http.ResponseWriter, r *http.Request) ([]clearance.Entry, bool) {
+	var body apiSelection
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !stderrors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return nil, false
+	}
+	entries, err := selectCleaners(a.registry, body.Cleaners)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return entries, true
+}
+
+func (a *apiServer) cleaners(w http.ResponseWriter, r *http.Request) {
+	cleaners := []apiCleaner{}
+	for _, e := range a.registry.Entries() {
+		status := cleanerStatus(r.Context(), e.New())
+		cleaners = append(cleaners, apiCleaner{
+			Key:         e.Key,
+			Description: e.Description,
+			Part:        e.Part,
+			InAll:       e.InAll,
+			Available:   status == "",
+			Status:      status,
+		})
+	}
+	writeJSON(w, http.StatusOK, cleaners)
+}
+
+func (a *apiServer) sizes(w http.ResponseWriter, r *http.Request) {
+	report := newReporter(a.registry).GetReport()
+	sizes := apiSizes{GeneratedAt: report.GeneratedAt, Total: report.Total, Caches: []apiSize{}, Volumes: []apiVolume{}}
+	for _, row := range report.Rows {
+		sizes.Caches = append(sizes.Caches, apiSize{
+			Cleaner: row.Name,
+			Label:   row.Label,
+			Part:    row.Part,
+			Bytes:   row.Bytes,
+			Status:  row.Status,
+			Volume:  row.Volume,
+			Share:   row.Share,
+		})
+	}
+	for _, v := range report.Volumes {
+		sizes.Volumes = append(sizes.Volumes, apiVolume{Path: v.Path, Total: v.Total, Free: v.Free})
+	}
+	writeJSON(w, http.StatusOK, sizes)
+}
+
+func (a *apiServer) plan(w http.ResponseWriter, r *http.Request) {
+	entries, ok := a.selection(w, r)
+	if !ok {
+		return
+	}
+	plans := []apiPlan{}
+	for _, e := range entries {
+		c := e.New()
+		p := apiPlan{Cleaner: c.GetName(), Items: []apiPlanItem{}}
+		plan, err := clearance.PlanCleaner(r.Context(), c)
+		var notSupported *errors.ErrNotSupported
+		switch {
+		case stderrors.As(err, &notSupported):
+			p.Error = "dry run is not supported"
+		case err != nil:
+			p.Error = err.Error()
+		default:
+			p.Reclaimable = plan.Reclaimable()
+			for _, item := range plan.Items {
+				p.Items = append(p.Items, apiPlanItem{Target: item.Target, Size: item.Size, Remove: item.Remove, Reason: item.Reason})
+			}
+		}
+		plans = append(plans, p)
+	}
+	writeJSON(w, http.StatusOK, plans)
+}
+
+func (a *apiServer) clean(w http.ResponseWriter, r *http.Request) {
+	entries, ok := a.selection(w, r)
+	if !ok {
+		return
+	}
+	cleanLock, err := lockCleaning()
+	if stderrors.Is(err, errCleanRunning) {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer cleanLock.Release()
+
+	run := newCleanupRun()
+	response := apiClean{RunID: run.run.ID, Results: []apiResult{}}
+	for _, e := range entries {
+		if a.ctx.Err() != nil {
+			break
+		}
+		outcome := run.clean(a.ctx, e.New())
+		result := apiResult{
+			Cleaner:     outcome.Result.CleanerName,
+			BytesBefore: outcome.Result.BytesBefore,
+			BytesAfter:  outcome.Result.BytesAfter,
+			Freed:       outcome.Result.Freed(),
+			Duration:    outcome.Result.Duration.Seconds(),
+		}
+		switch {
+		case outcome.InUse != nil:
+			result.Skipped = outcome.InUse.Error()
+		case outcome.Skipped != nil:
+			result.Skipped = outcome.Skipped.Error()
+		case outcome.Failed != nil:
+			result.Error = outcome.Result.Error.Error()
+		case outcome.Result.Error != nil && a.ctx.Err() != nil:
+			result.Error = "cancelled"
+		}
+		response.Results = append(response.Results, result)
+	}
+	run.finish(a.u)
+	response.Freed = run.freed()
+
+	if a.ctx.Err() != nil {
+		// What was removed before the shutdown is still reported
+		response.Cancelled = true
+		writeJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
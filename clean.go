@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/spf13/cobra"
+)
+
+// selectCleaners resolves cleaner keys or menu numbers, defaulting to the
+// cleaners selected by "all" when none are given
+func selectCleaners(registry *clearance.Registry, args []string) ([]clearance.Entry, error) {
+	if len(args) == 0 || (len(args) == 1 && strings.ToLower(args[0]) == "all") {
+		return registry.All(), nil
+	}
+
+	var entries []clearance.Entry
+	for _, arg := range args {
+		entry, ok := registry.Lookup(arg)
+		if !ok {
+			return nil, fmt.Errorf("unknown cleaner %q, see 'clearance list'", arg)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [cleaner...]",
+	Short: "Clean the given caches, or every enabled cache if none are given",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+		entries, err := selectCleaners(registry, args)
+		if err != nil {
+			return err
+		}
+
+		var options []string
+		for _, e := range entries {
+			options = append(options, e.Key)
+		}
+		return executeCleanup(cmd.Context(), ui.NewUI(), registry, options)
+	},
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan [cleaner...]",
+	Short: "Show what clean would remove without removing anything",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+		entries, err := selectCleaners(registry, args)
+		if err != nil {
+			return err
+		}
+
+		var cleaners []clearance.Cleaner
+		for _, e := range entries {
+			cleaners = append(cleaners, e.New())
+		}
+		showPlans(cmd.Context(), ui.NewUI(), cleaners)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd, planCmd)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/internal/lock"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// errCleanRunning is returned when another clearance process is cleaning
+var errCleanRunning = stderrors.New("another clearance clean is running")
+
+// lockCleaning takes the lock that keeps manual, scheduled and watch cleans
+// from running at the same time, whichever user runs them
+func lockCleaning() (*lock.Lock, error) {
+	path, err := lock.SharedPath("clean")
+	if err != nil {
+		return nil, err
+	}
+	l, err := lock.TryAcquire(path)
+	if stderrors.Is(err, lock.ErrLocked) {
+		if pid := lock.Holder(path); pid > 0 {
+			return nil, fmt.Errorf("%w (pid %d)", errCleanRunning, pid)
+		}
+		return nil, errCleanRunning
+	}
+	return l, err
+}
+
+// lockCleaner takes the lock on a single cleaner's cache, returning
+// *errors.ErrInUse if another process is cleaning it. Caches shared by the
+// machine are locked for every user, the others for the current one.
+func lockCleaner(c clearance.Cleaner) (*lock.Lock, error) {
+	name := c.GetName()
+	pathOf := lock.DefaultPath
+	if clearance.IsShared(c) {
+		pathOf = lock.SharedPath
+	}
+	path, err := pathOf(lock.CleanerName(name))
+	if err != nil {
+		return nil, err
+	}
+	l, err := lock.TryAcquire(path)
+	if stderrors.Is(err, lock.ErrLocked) {
+		reason := "another clearance process is cleaning it"
+		if pid := lock.Holder(path); pid > 0 {
+			reason = fmt.Sprintf("clearance (pid %d) is cleaning it", pid)
+		}
+		return nil, errors.NewErrInUse(name, reason)
+	}
+	return l, err
+}
+
+// openAudit opens the audit log for the run with the given ID, or returns
+// nil if it is turned off in the configuration file. Turning it off is still
+// recorded, so the log shows the runs it is missing.
+func openAudit(runID string) (*audit.Log, error) {
+	s := cfg.Settings.Audit
+	path := clearance.ExpandPath(s.Path)
+	if path == "" {
+		var err error
+		if path, err = audit.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	if s.Disabled {
+		// Best effort: it may be turned off because the log cannot be written
+		if l, err := audit.Open(path); err == nil {
+			reason := "audit log is disabled in the configuration"
+			if cfg.Profile != "" {
+				reason += fmt.Sprintf(" (profile %s)", cfg.Profile)
+			}
+			l.Run(runID).Guard("", audit.Skipped, reason)
+			_ = l.Close()
+		}
+		return nil, nil
+	}
+	return audit.Open(path)
+}
+
+// cleanerOutcome is what happened to one cleaner of a run
+type cleanerOutcome struct {
+	Result clearance.CleanResult
+	// Required is set when the cleaner was skipped for lack of privileges
+	Required clearance.Privileges
+	// Skipped is set when the cleaner could not run here
+	Skipped error
+	// InUse is set when the cache was in use and left alone; it may be
+	// cleaned once the tool using it is done
+	InUse *errors.ErrInUse
+	// Failed is set when the cleaner ran and failed
+	Failed *errors.ErrCleanupFailed
+}
+
+// cleanupRun runs cleaners one after another and records them in the history
+type cleanupRun struct {
+	run       *history.Run
+	auditLog  *audit.Log
+	auditErr  error
+	total     int
+	failures  []*errors.ErrCleanupFailed
+	skipped   []error
+	needAdmin []string
+}
+
+func newCleanupRun() *cleanupRun {
+	r := &cleanupRun{run: history.NewRun(history.KindClean)}
+	r.auditLog, r.auditErr = openAudit(r.run.ID)
+	return r
+}
+
+// clean runs c unless it needs privileges the process lacks or is not
+// supported here
+func (r *cleanupRun) clean(ctx context.Context, c clearance.Cleaner) cleanerOutcome {
+	r.total++
+	recorder := r.auditLog.Run(r.run.ID).Cleaner(c.GetName())
+	ctx = audit.NewContext(ctx, recorder)
+
+	required := clearance.RequiredPrivileges(ctx, c)
+	if err := clearance.CheckPrivileges(required); err != nil {
+		r.skipped = append(r.skipped, err)
+		r.needAdmin = append(r.needAdmin, fmt.Sprintf("%s (%s)", c.GetName(), required))
+		recorder.Guard("", audit.Skipped, err.Error())
+		r.run.Add(history.Entry{Cleaner: c.GetName(), BytesBefore: -1, BytesAfter: -1, Error: err.Error()})
+		return cleanerOutcome{Result: clearance.CleanResult{CleanerName: c.GetName(), Error: err}, Required: required, Skipped: err}
+	}
+
+	// The cache is left alone while another process cleans or uses it
+	cleanerLock, err := lockCleaner(c)
+	if err == nil {
+		defer cleanerLock.Release()
+		err = clearance.CheckInUse(ctx, c)
+	}
+	var inUse *errors.ErrInUse
+	switch {
+	case stderrors.As(err, &inUse):
+		recorder.Guard("", audit.Skipped, "in use: "+inUse.Reason)
+		r.skipped = append(r.skipped, err)
+		r.run.Add(history.Entry{Cleaner: c.GetName(), BytesBefore: -1, BytesAfter: -1, Error: err.Error()})
+		return cleanerOutcome{Result: clearance.CleanResult{CleanerName: c.GetName(), Error: err}, InUse: inUse}
+	case err != nil:
+		failed := errors.WrapCleanupFailed(c.GetName(), err)
+		r.failures = append(r.failures, failed)
+		r.run.Add(history.Entry{Cleaner: c.GetName(), BytesBefore: -1, BytesAfter: -1, Error: err.Error()})
+		return cleanerOutcome{Result: clearance.CleanResult{CleanerName: c.GetName(), Error: err}, Failed: failed}
+	}
+
+	result := clearance.Execute(ctx, c)
+	var safety *errors.ErrSafetyViolation
+	if stderrors.As(result.Error, &safety) {
+		recorder.Guard(safety.Target, audit.Refused, safety.Reason)
+	}
+	recorder.Cleaned(result.Freed(), result.Error)
+	outcome := cleanerOutcome{Result: result}
+	entry := history.Entry{
+		Cleaner:     result.CleanerName,
+		BytesBefore: result.BytesBefore,
+		BytesAfter:  result.BytesAfter,
+		Freed:       result.Freed(),
+		Duration:    result.Duration,
+	}
+
+	var notSupported *errors.ErrNotSupported
+	switch {
+	case result.Error == nil:
+	case ctx.Err() != nil:
+		entry.Error = "cancelled"
+	case stderrors.As(result.Error, &notSupported):
+		r.skipped = append(r.skipped, result.Error)
+		outcome.Skipped = result.Error
+		entry.Error = result.Error.Error()
+	default:
+		outcome.Failed = errors.WrapCleanupFailed(result.CleanerName, result.Error)
+		r.failures = append(r.failures, outcome.Failed)
+		entry.Error = result.Error.Error()
+	}
+	r.run.Add(entry)
+	return outcome
+}
+
+// finish records the run in the history and closes the audit log
+func (r *cleanupRun) finish(u *ui.UI) {
+	r.run.Finish()
+	recordRun(u, r.run)
+
+	err := r.auditErr
+	if r.auditLog != nil {
+		err = stderrors.Join(r.auditLog.Err(), r.auditLog.Close())
+	}
+	if err != nil {
+		u.ShowWarning(fmt.Sprintf("Could not write the audit log: %v", err))
+	}
+}
+
+// freed returns the space freed by every cleaner so far
+func (r *cleanupRun) freed() int64 {
+	return r.run.Freed()
+}
+
+// err summarizes the run: ctx's error if it was cancelled, the reasons
+// cleaners were skipped if none could run, or the failures of the others
+func (r *cleanupRun) err(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.total-len(r.skipped) == 0 {
+		return stderrors.Join(r.skipped...)
+	}
+	if len(r.failures) > 0 {
+		return &errors.ErrCleanupFailures{Failures: r.failures, Total: r.total - len(r.skipped)}
+	}
+	return nil
+}
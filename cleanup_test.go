@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"testing"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// fakeCleaner fails to clean with err, reports inUse from its in-use check
+// and needs the given privileges
+type fakeCleaner struct {
+	name     string
+	err      error
+	inUse    error
+	required clearance.Privileges
+}
+
+func (c *fakeCleaner) Clean(ctx context.Context) error             { return c.err }
+func (c *fakeCleaner) GetSize(ctx context.Context) (string, error) { return "N/A", nil }
+func (c *fakeCleaner) GetName() string                             { return c.name }
+func (c *fakeCleaner) InUse(ctx context.Context) error             { return c.inUse }
+func (c *fakeCleaner) RequiredPrivileges(ctx context.Context) clearance.Privileges {
+	return c.required
+}
+
+func TestCleanupRunErr(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("APPDATA", dir)
+
+	failed := stderrors.New("exit status 1")
+	notSupported := errors.NewErrNotSupported("clean", "Windows only")
+	safety := errors.NewErrSafetyViolation("/", "file system root")
+	inUse := errors.NewErrInUse("npm", "npm is running")
+	admin := clearance.Privileges{Admin: true}
+
+	tests := []struct {
+		name     string
+		cleaners []*fakeCleaner
+		cancel   bool
+		want     int
+		failures int
+		total    int
+	}{
+		{name: "all succeed", cleaners: []*fakeCleaner{{name: "a"}, {name: "b"}}, want: errors.ExitOK},
+		{name: "one of two fails", cleaners: []*fakeCleaner{{name: "a"}, {name: "b", err: failed}},
+			want: errors.ExitPartial, failures: 1, total: 2},
+		{name: "all fail", cleaners: []*fakeCleaner{{name: "a", err: failed}, {name: "b", err: failed}},
+			want: errors.ExitFailure, failures: 2, total: 2},
+		{name: "skipped cleaners do not count", cleaners: []*fakeCleaner{{name: "a", err: notSupported}, {name: "b", err: failed}},
+			want: errors.ExitFailure, failures: 1, total: 1},
+		{name: "skipped and succeeded", cleaners: []*fakeCleaner{{name: "a", err: notSupported}, {name: "b"}},
+			want: errors.ExitOK},
+		{name: "all not supported", cleaners: []*fakeCleaner{{name: "a", err: notSupported}, {name: "b", err: notSupported}},
+			want: errors.ExitNotSupported},
+		{name: "all in use", cleaners: []*fakeCleaner{{name: "a", inUse: inUse}},
+			want: errors.ExitInUse},
+		{name: "in use and not supported", cleaners: []*fakeCleaner{{name: "a", err: notSupported}, {name: "b", inUse: inUse}},
+			want: errors.ExitInUse},
+		{name: "safety violation", cleaners: []*fakeCleaner{{name: "a"}, {name: "b", err: safety}},
+			want: errors.ExitSafetyViolation, failures: 1, total: 2},
+		{name: "needs admin", cleaners: []*fakeCleaner{{name: "a", required: admin}, {name: "b", err: notSupported}},
+			want: errors.ExitAdminRequired},
+		{name: "cancelled", cleaners: []*fakeCleaner{{name: "a", err: failed}}, cancel: true,
+			want: errors.ExitCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range tt.cleaners {
+				if clearance.CheckPrivileges(c.required) == nil && !c.required.IsZero() {
+					t.Skip("the process already has the privileges the cleaner needs")
+				}
+			}
+			ctx, cancel := context.WithCancel(clearance.WithOutput(context.Background(), io.Discard))
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			r := &cleanupRun{run: history.NewRun(history.KindClean)}
+			for _, c := range tt.cleaners {
+				r.clean(ctx, c)
+			}
+			err := r.err(ctx)
+			if got := errors.ExitCode(err); got != tt.want {
+				t.Errorf("err() = %v, exit code %d, want %d", err, got, tt.want)
+			}
+
+			var failures *errors.ErrCleanupFailures
+			if !stderrors.As(err, &failures) {
+				if tt.failures > 0 {
+					t.Errorf("err() = %v, want %d failures", err, tt.failures)
+				}
+				return
+			}
+			if len(failures.Failures) != tt.failures || failures.Total != tt.total {
+				t.Errorf("err() = %d of %d failed, want %d of %d", len(failures.Failures), failures.Total, tt.failures, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/abdorrahmani/clearance/internal/config"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the configuration file",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration file and every profile for errors",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui := ui.NewUI()
+		if len(cfg.Sources) == 0 {
+			ui.ShowWarning("No configuration file found")
+			return nil
+		}
+
+		// Only the cleaner names are needed, so the settings being validated
+		// are not applied to the registry
+		registry := clearance.NewDefaultRegistry(clearance.RegistryOptions{ContainerdNamespaces: containerdNamespaces})
+		known := registry.Keys()
+		if !cfg.Settings.Plugins.Disabled {
+			known = append(known, clearance.PluginNames(os.Getenv("PATH"))...)
+		}
+		errs := cfg.Validate(known)
+		for _, err := range errs {
+			ui.ShowError(err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("configuration has %d error(s)", len(errs))
+		}
+		for _, source := range cfg.Sources {
+			ui.ShowSuccess(fmt.Sprintf("%s is valid", source))
+		}
+		return nil
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Show where configuration files are read from",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userFile := configPath
+		if userFile == "" {
+			path, err := config.UserFile()
+			if err != nil {
+				return err
+			}
+			userFile = path
+		}
+		fmt.Println(userFile)
+
+		if wd, err := os.Getwd(); err == nil {
+			if local := config.LocalFile(wd); local != "" {
+				fmt.Println(local)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd, configPathCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// exploreRoots resolves a cleaner key or a directory to the directories to
+// explore, and the cleaner if it is one
+func exploreRoots(arg string) ([]string, clearance.Cleaner, error) {
+	registry, err := newRegistry()
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry, ok := registry.Lookup(arg); ok {
+		c := entry.New()
+		paths, err := clearance.CachePaths(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		var roots []string
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				roots = append(roots, path)
+			}
+		}
+		if len(roots) == 0 {
+			return nil, nil, fmt.Errorf("no cache directories found for %s", entry.Key)
+		}
+		return roots, c, nil
+	}
+
+	path, err := filepath.Abs(arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, nil, fmt.Errorf("%q is neither a cleaner nor a directory, see 'clearance list'", arg)
+	}
+	return []string{path}, nil, nil
+}
+
+// exploreEntry converts a scanned tree for the explorer
+func exploreEntry(node *clearance.DiskNode) *ui.ExploreEntry {
+	entry := &ui.ExploreEntry{
+		Name:     node.Name,
+		Path:     node.Path,
+		Size:     node.Size,
+		Modified: node.Modified,
+		Dir:      node.IsDir,
+	}
+	if node.Err != nil {
+		entry.Error = "not fully readable"
+	}
+	for _, child := range node.Children {
+		entry.Children = append(entry.Children, exploreEntry(child))
+	}
+	return entry
+}
+
+// removeExplored removes path through the safety guard, checked against the
+// explored directory that contains it, and records it in the audit log. When
+// a cleaner's cache is explored, nothing is removed while it is in use or
+// being cleaned by another clearance process.
+func removeExplored(ctx context.Context, c clearance.Cleaner, roots []string, path string) error {
+	recorder := audit.FromContext(ctx)
+	if c != nil {
+		cleanerLock, err := lockCleaner(c)
+		if err == nil {
+			defer cleanerLock.Release()
+			err = clearance.CheckInUse(ctx, c)
+		}
+		var inUse *errors.ErrInUse
+		if stderrors.As(err, &inUse) {
+			recorder.Guard(path, audit.Refused, "in use: "+inUse.Reason)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	root := roots[0]
+	for _, r := range roots {
+		if path == r || strings.HasPrefix(path, r+string(filepath.Separator)) {
+			root = r
+		}
+	}
+
+	size := int64(-1)
+	if recorder != nil {
+		if measured, err := clearance.MeasurePath(path); err == nil {
+			size = measured
+		}
+	}
+	err := clearance.RemoveWithin(root, path)
+	var safety *errors.ErrSafetyViolation
+	if stderrors.As(err, &safety) {
+		recorder.Guard(safety.Target, audit.Refused, safety.Reason)
+		return err
+	}
+	recorder.Removed(path, size, err)
+	return err
+}
+
+var exploreCmd = &cobra.Command{
+	Use:   "explore <cleaner|directory>",
+	Short: "Browse a cache or directory by size and delete individual entries",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !ui.TUISupported() {
+			return fmt.Errorf("explore needs an interactive terminal")
+		}
+		roots, c, err := exploreRoots(args[0])
+		if err != nil {
+			return err
+		}
+
+		u := ui.NewUI()
+		u.ShowInfo(fmt.Sprintf("Scanning %s...", strings.Join(roots, ", ")))
+		var root *ui.ExploreEntry
+		for _, path := range roots {
+			node, err := clearance.ScanTree(cmd.Context(), path)
+			if err != nil {
+				return err
+			}
+			if len(roots) == 1 {
+				root = exploreEntry(node)
+				break
+			}
+			if root == nil {
+				root = &ui.ExploreEntry{Name: args[0], Dir: true}
+			}
+			child := exploreEntry(node)
+			child.Name = path
+			root.Children = append(root.Children, child)
+			root.Size += child.Size
+		}
+
+		runID := history.NewID()
+		auditLog, err := openAudit(runID)
+		if err != nil {
+			u.ShowWarning(fmt.Sprintf("Could not open the audit log: %v", err))
+		}
+		// Deletions are recorded under the explored cleaner or directory
+		ctx := audit.NewContext(cmd.Context(), auditLog.Run(runID).Cleaner(args[0]))
+		defer func() {
+			if auditLog == nil {
+				return
+			}
+			if err := stderrors.Join(auditLog.Err(), auditLog.Close()); err != nil {
+				u.ShowWarning(fmt.Sprintf("Could not write the audit log: %v", err))
+			}
+		}()
+
+		freed, err := ui.RunExplorer("Clearance explore: "+args[0], root, ui.ExploreHooks{
+			Delete:     func(path string) error { return removeExplored(ctx, c, roots, path) },
+			FormatSize: clearance.FormatSize,
+		})
+		if err != nil {
+			return err
+		}
+		if freed > 0 {
+			u.ShowSuccess(fmt.Sprintf("Freed %s", clearance.FormatSize(freed)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exploreCmd)
+}
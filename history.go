@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit   int
+	historyCleaner string
+)
+
+// formatBytes formats a recorded size, where a negative size means unknown
+func formatBytes(size int64) string {
+	if size < 0 {
+		return "N/A"
+	}
+	return clearance.FormatSize(size)
+}
+
+// loadHistory reads all recorded runs from the default store
+func loadHistory() ([]history.Run, error) {
+	store, err := history.NewDefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recorded report and clean runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runs, err := loadHistory()
+		if err != nil {
+			return err
+		}
+		if historyLimit > 0 && len(runs) > historyLimit {
+			runs = runs[len(runs)-historyLimit:]
+		}
+
+		var rows []ui.HistoryRow
+		for _, run := range runs {
+			for _, e := range run.Entries {
+				if historyCleaner != "" && e.Cleaner != historyCleaner {
+					continue
+				}
+				rows = append(rows, ui.HistoryRow{
+					Time:     run.StartedAt.Format("2006-01-02 15:04"),
+					Kind:     run.Kind,
+					Cleaner:  e.Cleaner,
+					Before:   formatBytes(e.BytesBefore),
+					After:    formatBytes(e.BytesAfter),
+					Freed:    formatBytes(e.Freed),
+					Duration: e.Duration.Round(100 * time.Millisecond).String(),
+					Error:    e.Error,
+				})
+			}
+		}
+
+		ui.NewUI().ShowHistory(rows)
+		return nil
+	},
+}
+
+var trendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show how fast each cache regrows and the space reclaimed over time",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runs, err := loadHistory()
+		if err != nil {
+			return err
+		}
+
+		var rows []ui.TrendRow
+		var totalFreed int64
+		for _, t := range history.Trends(runs) {
+			lastCleaned := "never"
+			if !t.LastCleaned.IsZero() {
+				lastCleaned = t.LastCleaned.Format("2006-01-02 15:04")
+			}
+			rows = append(rows, ui.TrendRow{
+				Cleaner:     t.Cleaner,
+				Runs:        strconv.Itoa(t.Runs),
+				LastSize:    formatBytes(t.LastSize),
+				Growth:      formatBytes(int64(t.GrowthPerDay)),
+				TotalFreed:  formatBytes(t.TotalFreed),
+				LastCleaned: lastCleaned,
+			})
+			totalFreed += t.TotalFreed
+		}
+
+		ui.NewUI().ShowTrends(rows, formatBytes(totalFreed))
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "number of most recent runs to show (0 for all)")
+	historyCmd.Flags().StringVar(&historyCleaner, "cleaner", "", "only show entries for this cleaner")
+	rootCmd.AddCommand(historyCmd, trendsCmd)
+}
@@ -0,0 +1,245 @@
+// Package audit writes an append-only log, one JSON object per line, of
+// every path clearance removes, every command it runs and every decision
+// its safety guards make
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event kinds
+const (
+	// KindRemove is a removed path or container engine object
+	KindRemove = "remove"
+	// KindCommand is an external command that ran
+	KindCommand = "command"
+	// KindGuard is something a safety guard kept, skipped or refused to remove
+	KindGuard = "guard"
+	// KindClean is the outcome of a cleaner
+	KindClean = "clean"
+)
+
+// Guard decisions
+const (
+	// Kept means the target was left alone by policy, e.g. a protected image
+	Kept = "kept"
+	// Skipped means the whole cache was left alone, e.g. because it is in use
+	Skipped = "skipped"
+	// Refused means removing the target would have been unsafe
+	Refused = "refused"
+)
+
+// Event is a single line of the audit log
+type Event struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	RunID   string    `json:"run_id,omitempty"`
+	Cleaner string    `json:"cleaner,omitempty"`
+	Kind    string    `json:"kind"`
+	// Object is the kind of container engine object removed, e.g. "image",
+	// empty for files and directories
+	Object string `json:"object,omitempty"`
+	// Path is the removed or guarded path, or the object's ID or name
+	Path string `json:"path,omitempty"`
+	// Size is the size in bytes of what was removed, -1 if unknown
+	Size *int64 `json:"size,omitempty"`
+	// Args are the command and its arguments
+	Args     []string `json:"args,omitempty"`
+	ExitCode *int     `json:"exit_code,omitempty"`
+	Decision string   `json:"decision,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	// Freed is the space a cleaner freed in bytes
+	Freed *int64 `json:"freed,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// DefaultPath returns the audit log in the user config directory, e.g.
+// ~/.config/clearance/audit.jsonl on Linux
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "clearance", "audit.jsonl"), nil
+}
+
+// Log is an open audit log. Events are only ever appended.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	user string
+	host string
+	// err is the first write error, reported by Err
+	err error
+}
+
+// Open opens the audit log at path for appending, creating it readable by
+// the owner only if it does not exist
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{f: f}
+	if u, err := user.Current(); err == nil {
+		l.user = u.Username
+	} else if name := os.Getenv("USER"); name != "" {
+		l.user = name
+	} else {
+		l.user = os.Getenv("USERNAME")
+	}
+	l.host, _ = os.Hostname()
+	return l, nil
+}
+
+// write appends e as one line. Each event is a single write, so lines from
+// processes sharing the log are not interleaved.
+func (l *Log) write(e Event) {
+	e.Time = time.Now().UTC()
+	e.User = l.user
+	e.Host = l.host
+	e.PID = os.Getpid()
+	data, err := json.Marshal(e)
+	if err == nil {
+		l.mu.Lock()
+		_, err = l.f.Write(append(data, '\n'))
+		l.mu.Unlock()
+	}
+	if err != nil {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Err returns the first error writing an event, if any
+func (l *Log) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close closes the log
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Run returns a recorder for the run with the given ID
+func (l *Log) Run(runID string) *Recorder {
+	if l == nil {
+		return nil
+	}
+	return &Recorder{log: l, runID: runID}
+}
+
+// Recorder records the events of a run, and of one cleaner in it. Its
+// methods do nothing on a nil Recorder, so code that is not being audited
+// need not check.
+type Recorder struct {
+	log     *Log
+	runID   string
+	cleaner string
+}
+
+// Cleaner returns a recorder for one cleaner of the run
+func (r *Recorder) Cleaner(name string) *Recorder {
+	if r == nil {
+		return nil
+	}
+	return &Recorder{log: r.log, runID: r.runID, cleaner: name}
+}
+
+func (r *Recorder) record(e Event) {
+	if r == nil {
+		return
+	}
+	e.RunID = r.runID
+	e.Cleaner = r.cleaner
+	r.log.write(e)
+}
+
+// Removed records the removal of path, size bytes or -1 if unknown, and
+// the error if it failed
+func (r *Recorder) Removed(path string, size int64, err error) {
+	r.RemovedObject("", path, size, err)
+}
+
+// RemovedObject records the removal of a container engine object, e.g. an
+// image by ID
+func (r *Recorder) RemovedObject(object, id string, size int64, err error) {
+	r.record(Event{Kind: KindRemove, Object: object, Path: id, Size: &size, Error: errorString(err)})
+}
+
+// Command records a command that ran, or failed to start, with err being
+// what Run returned
+func (r *Recorder) Command(cmd *exec.Cmd, err error) {
+	if r == nil {
+		return
+	}
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && code >= 0 {
+		// The exit code says it all
+		err = nil
+	}
+	r.record(Event{Kind: KindCommand, Args: cmd.Args, ExitCode: &code, Error: errorString(err)})
+}
+
+// Guard records a safety decision about target
+func (r *Recorder) Guard(target, decision, reason string) {
+	r.GuardObject("", target, decision, reason)
+}
+
+// GuardObject records a safety decision about a container engine object
+func (r *Recorder) GuardObject(object, id, decision, reason string) {
+	r.record(Event{Kind: KindGuard, Object: object, Path: id, Decision: decision, Reason: reason})
+}
+
+// Cleaned records the outcome of the cleaner
+func (r *Recorder) Cleaned(freed int64, err error) {
+	r.record(Event{Kind: KindClean, Freed: &freed, Error: errorString(err)})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying r, so the cleaners called with it record
+// what they do
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	if r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the recorder carried by ctx, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
@@ -0,0 +1,15 @@
+package cleaner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when info's file was last read
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atimespec.Unix())
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package cleaner
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns when info's file was last modified, as access times
+// are not read on this system
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
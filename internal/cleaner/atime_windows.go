@@ -0,0 +1,15 @@
+package cleaner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when info's file was last read
+func accessTime(info os.FileInfo) time.Time {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, data.LastAccessTime.Nanoseconds())
+	}
+	return info.ModTime()
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // BaseCleaner provides common functionality for all cleaners
@@ -38,6 +40,19 @@ func GetDirSize(path string) (int64, error) {
 	return size, err
 }
 
+// MeasurePath returns the size of a directory in bytes, treating a missing
+// directory as empty
+func MeasurePath(path string) (int64, error) {
+	exists, err := CheckPathExists(path)
+	if err != nil {
+		return -1, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	return GetDirSize(path)
+}
+
 // FormatSize converts bytes to human-readable format
 func FormatSize(size int64) string {
 	const unit = 1024
@@ -52,6 +67,26 @@ func FormatSize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
+// ParseSize converts a human-readable size such as "5GB", "512M" or "1024" to bytes
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "IB"), "B")
+	multiplier := int64(1)
+	if n := len(str); n > 0 {
+		if exp := strings.IndexByte("KMGTPE", str[n-1]); exp >= 0 {
+			for i := 0; i <= exp; i++ {
+				multiplier *= 1024
+			}
+			str = str[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
 // CheckPathExists checks if a path exists
 func CheckPathExists(path string) (bool, error) {
 	_, err := os.Stat(path)
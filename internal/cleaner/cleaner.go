@@ -2,10 +2,7 @@ package cleaner
 
 import (
 	"context"
-	"os"
-	"runtime"
-
-	"github.com/abdorrahmani/clearance/pkg/errors"
+	"time"
 )
 
 // Cleaner defines the interface for cache cleaning operations
@@ -18,12 +15,92 @@ type Cleaner interface {
 	GetName() string
 }
 
+// Measurer is implemented by cleaners that can measure their cache in bytes
+type Measurer interface {
+	// Measure returns the current size of the cache in bytes, 0 if the cache
+	// does not exist, or -1 if it cannot be measured on this machine
+	Measure(ctx context.Context) (int64, error)
+}
+
+// Locator is implemented by cleaners whose caches are directories on disk
+type Locator interface {
+	// CachePaths returns the directories the cleaner cleans
+	CachePaths() []string
+}
+
+// Sharer is implemented by cleaners whose cache belongs to the machine
+// rather than the user running them, such as a container engine's
+type Sharer interface {
+	// SharedCache reports whether every user of the machine cleans the
+	// same cache
+	SharedCache() bool
+}
+
+// IsShared reports whether c's cache is shared by every user of the machine
+func IsShared(c Cleaner) bool {
+	s, ok := c.(Sharer)
+	return ok && s.SharedCache()
+}
+
+// Checker is implemented by cleaners that can tell whether they can run on
+// this machine without measuring or cleaning anything
+type Checker interface {
+	// Available returns nil if the cleaner can run, or an error explaining
+	// why it cannot, e.g. because the tool is not installed
+	Available(ctx context.Context) error
+}
+
 // CleanResult represents the result of a cleaning operation
 type CleanResult struct {
 	CleanerName string
 	Error       error
 	SizeBefore  string
 	SizeAfter   string
+	BytesBefore int64
+	BytesAfter  int64
+	Duration    time.Duration
+}
+
+// Freed returns the number of bytes the operation freed, or 0 if unknown
+func (r CleanResult) Freed() int64 {
+	if r.BytesBefore < 0 || r.BytesAfter < 0 {
+		return 0
+	}
+	return max(r.BytesBefore-r.BytesAfter, 0)
+}
+
+// Execute runs a cleaner, measuring the cache before and after when the
+// cleaner supports it
+func Execute(ctx context.Context, c Cleaner) CleanResult {
+	result := CleanResult{
+		CleanerName: c.GetName(),
+		BytesBefore: -1,
+		BytesAfter:  -1,
+	}
+
+	measurer, canMeasure := c.(Measurer)
+	if canMeasure {
+		result.BytesBefore, _ = measurer.Measure(ctx)
+	}
+
+	start := time.Now()
+	result.Error = c.Clean(ctx)
+	result.Duration = time.Since(start)
+
+	if canMeasure {
+		result.BytesAfter, _ = measurer.Measure(ctx)
+	}
+	result.SizeBefore = formatMeasured(result.BytesBefore)
+	result.SizeAfter = formatMeasured(result.BytesAfter)
+	return result
+}
+
+// formatMeasured formats a measured size, where -1 means unknown
+func formatMeasured(size int64) string {
+	if size < 0 {
+		return "N/A"
+	}
+	return FormatSize(size)
 }
 
 // CleanOptions represents the options for cleaning operations
@@ -37,15 +114,3 @@ type CleanOptions struct {
 	CleanAll           bool
 	ReportSize         bool
 }
-
-// CheckAdminPrivileges checks if the program is running with administrator privileges
-func CheckAdminPrivileges() error {
-	if runtime.GOOS == "windows" {
-		// Try to open a system file that requires admin rights
-		_, err := os.Open("\\\\.\\PHYSICALDRIVE0")
-		if err != nil {
-			return errors.NewErrAdminRequired("this program requires administrator privileges")
-		}
-	}
-	return nil
-}
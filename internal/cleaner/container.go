@@ -0,0 +1,358 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// ContainerEngine is the container runtime API a ContainerCleaner drives.
+// The Docker Engine API client implements it, as does the Podman socket
+// through its Docker-compatible API and the nerdctl CLI wrapper.
+type ContainerEngine interface {
+	// Host returns the address or namespace the engine is connected to
+	Host() string
+	// Ping checks whether the runtime is reachable
+	Ping(ctx context.Context) error
+	// DiskUsage returns disk usage broken down by category
+	DiskUsage(ctx context.Context) (*docker.DiskUsage, error)
+	// PruneContainers removes stopped containers
+	PruneContainers(ctx context.Context, filters docker.Filters) (*docker.PruneReport, error)
+	// PruneNetworks removes unused networks
+	PruneNetworks(ctx context.Context, filters docker.Filters) (*docker.PruneReport, error)
+	// PruneBuildCache removes build cache records
+	PruneBuildCache(ctx context.Context, all bool, keepStorage int64, filters docker.Filters) (*docker.PruneReport, error)
+	// RemoveImage removes an image by ID or untags it by reference
+	RemoveImage(ctx context.Context, ref string) error
+	// RemoveVolume removes a volume by name
+	RemoveVolume(ctx context.Context, name string) error
+}
+
+// ContainerCleaner handles cleaning of container runtime caches using the
+// Docker-style cleanup modes
+type ContainerCleaner struct {
+	*BaseCleaner
+	runtime   string
+	newEngine func() (ContainerEngine, error)
+	modes     []string
+	options   DockerOptions
+	reclaimed int64
+}
+
+// NewContainerCleaner creates a ContainerCleaner running the given modes
+// against the engine returned by newEngine
+func NewContainerCleaner(name, runtime string, newEngine func() (ContainerEngine, error), modes []string, options DockerOptions) *ContainerCleaner {
+	return &ContainerCleaner{
+		BaseCleaner: NewBaseCleaner(name),
+		runtime:     runtime,
+		newEngine:   newEngine,
+		modes:       modes,
+		options:     options,
+	}
+}
+
+// SharedCache reports true: every user of the machine talks to the same
+// engine
+func (d *ContainerCleaner) SharedCache() bool {
+	return true
+}
+
+// connect creates the engine and verifies the runtime is reachable
+func (d *ContainerCleaner) connect(ctx context.Context) (ContainerEngine, error) {
+	engine, err := d.newEngine()
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("%s is not running at %s", d.runtime, engine.Host())
+	}
+	return engine, nil
+}
+
+// Clean performs the container cache cleaning operation
+func (d *ContainerCleaner) Clean(ctx context.Context) error {
+	name := d.GetName()
+	printf(ctx, "[%s] Connecting to %s...\n", name, d.runtime)
+	engine, err := d.connect(ctx)
+	if err != nil {
+		printf(ctx, "[%s] %v\n", name, err)
+		return err
+	}
+
+	d.reclaimed = 0
+	for _, mode := range d.modes {
+		var report *docker.PruneReport
+		switch mode {
+		case DockerModeDangling, DockerModeImages, DockerModeVolumes:
+			report, err = d.removePlanned(ctx, engine, mode)
+		default:
+			report, err = d.prune(ctx, engine, mode)
+			if err == nil {
+				// Prune calls only report the total reclaimed
+				for _, id := range report.Deleted {
+					audit.FromContext(ctx).RemovedObject(DockerModeDescription(mode), id, -1, nil)
+				}
+			}
+		}
+		if err != nil {
+			printf(ctx, "[%s] Failed to prune %s: %v\n", name, DockerModeDescription(mode), err)
+			return fmt.Errorf("failed to prune %s: %w", DockerModeDescription(mode), err)
+		}
+		d.reclaimed += report.SpaceReclaimed
+		printf(ctx, "[%s] Pruned %s: %d removed, %s reclaimed\n", name, DockerModeDescription(mode), len(report.Deleted), FormatSize(report.SpaceReclaimed))
+	}
+
+	printf(ctx, "[%s] %s cleanup completed. Total reclaimed space: %s\n", name, d.runtime, FormatSize(d.reclaimed))
+	return nil
+}
+
+// removePlanned removes the images or volumes selected by the plan one by
+// one, so that protected items survive
+func (d *ContainerCleaner) removePlanned(ctx context.Context, engine ContainerEngine, mode string) (*docker.PruneReport, error) {
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &docker.PruneReport{}
+	name := d.GetName()
+	recorder := audit.FromContext(ctx)
+
+	if mode == DockerModeVolumes {
+		for _, item := range d.planVolumes(du) {
+			if !item.Remove {
+				printf(ctx, "[%s] Keeping volume %s (%s)\n", name, item.Target, item.Reason)
+				recorder.GuardObject("volume", item.Target, audit.Kept, item.Reason)
+				continue
+			}
+			err := engine.RemoveVolume(ctx, item.Target)
+			recorder.RemovedObject("volume", item.Target, item.Size, err)
+			if err != nil {
+				printf(ctx, "[%s] Failed to remove volume %s: %v\n", name, item.Target, err)
+				continue
+			}
+			report.Deleted = append(report.Deleted, item.Target)
+			report.SpaceReclaimed += max(item.Size, 0)
+		}
+		return report, nil
+	}
+
+	images, items := d.planImages(du, mode)
+	for i, img := range images {
+		item := items[i]
+		if !item.Remove {
+			printf(ctx, "[%s] Keeping image %s (%s)\n", name, item.Target, item.Reason)
+			recorder.GuardObject("image", item.Target, audit.Kept, item.Reason)
+			continue
+		}
+		refs := imageTags(img)
+		if len(refs) == 0 {
+			refs = []string{img.ID}
+		}
+		removed := true
+		for i, ref := range refs {
+			err := engine.RemoveImage(ctx, ref)
+			// The space is only freed once the last tag is removed
+			size := int64(-1)
+			if i == len(refs)-1 {
+				size = item.Size
+			}
+			recorder.RemovedObject("image", ref, size, err)
+			if err != nil {
+				printf(ctx, "[%s] Failed to remove image %s: %v\n", name, ref, err)
+				removed = false
+				break
+			}
+		}
+		if removed {
+			report.Deleted = append(report.Deleted, img.ID)
+			report.SpaceReclaimed += max(item.Size, 0)
+		}
+	}
+	return report, nil
+}
+
+// prune runs the prune call backing a container, network or build cache mode
+func (d *ContainerCleaner) prune(ctx context.Context, engine ContainerEngine, mode string) (*docker.PruneReport, error) {
+	switch mode {
+	case DockerModeContainers:
+		return engine.PruneContainers(ctx, nil)
+	case DockerModeNetworks:
+		return engine.PruneNetworks(ctx, nil)
+	case DockerModeBuildCache:
+		return engine.PruneBuildCache(ctx, true, d.options.KeepStorage, nil)
+	default:
+		return nil, fmt.Errorf("unknown %s cleanup mode: %s", d.runtime, mode)
+	}
+}
+
+// Reclaimed returns the space reclaimed by the last Clean call in bytes
+func (d *ContainerCleaner) Reclaimed() int64 {
+	return d.reclaimed
+}
+
+// DiskUsage returns disk usage broken down by category
+func (d *ContainerCleaner) DiskUsage(ctx context.Context) ([]docker.CategoryUsage, error) {
+	engine, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return du.Categories(), nil
+}
+
+// Plan returns the containers, images, volumes and caches Clean would remove
+func (d *ContainerCleaner) Plan(ctx context.Context) (*Plan, error) {
+	engine, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{CleanerName: d.GetName()}
+	for _, mode := range d.modes {
+		plan.Items = append(plan.Items, d.planMode(du, mode)...)
+	}
+	return plan, nil
+}
+
+// RequiredPrivileges returns the privileges needed to reach the runtime's
+// socket, e.g. root or the docker group for a rootful Docker daemon
+func (d *ContainerCleaner) RequiredPrivileges(ctx context.Context) Privileges {
+	engine, err := d.newEngine()
+	if err != nil {
+		return Privileges{}
+	}
+	if path, ok := strings.CutPrefix(engine.Host(), "unix://"); ok {
+		return socketPrivileges(path)
+	}
+	return Privileges{}
+}
+
+// Available checks that the container runtime is installed and running
+func (d *ContainerCleaner) Available(ctx context.Context) error {
+	_, err := d.connect(ctx)
+	return err
+}
+
+// GetSize returns the space the cleaner's modes would reclaim
+func (d *ContainerCleaner) GetSize(ctx context.Context) (string, error) {
+	engine, err := d.newEngine()
+	if err != nil {
+		return "Not installed", nil
+	}
+	if err := engine.Ping(ctx); err != nil {
+		return "Not running", nil
+	}
+
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return "Error getting size", nil
+	}
+
+	reclaimable := d.reclaimable(du)
+	if len(d.modes) == 1 {
+		return fmt.Sprintf("%s reclaimable", FormatSize(reclaimable)), nil
+	}
+	size, _ := du.Total()
+	return fmt.Sprintf("%s (%s reclaimable)", FormatSize(size), FormatSize(reclaimable)), nil
+}
+
+// Measure returns the total size for multi-mode cleaners and the reclaimable
+// size for single-mode ones, or -1 if the runtime is not available
+func (d *ContainerCleaner) Measure(ctx context.Context) (int64, error) {
+	engine, err := d.newEngine()
+	if err != nil {
+		return -1, nil
+	}
+	if err := engine.Ping(ctx); err != nil {
+		return -1, nil
+	}
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if len(d.modes) == 1 {
+		return d.reclaimable(du), nil
+	}
+	size, _ := du.Total()
+	return size, nil
+}
+
+// reclaimable returns the bytes the cleaner's modes would free
+func (d *ContainerCleaner) reclaimable(du *docker.DiskUsage) int64 {
+	plan := &Plan{CleanerName: d.GetName()}
+	for _, mode := range d.modes {
+		plan.Items = append(plan.Items, d.planMode(du, mode)...)
+	}
+	return plan.Reclaimable()
+}
+
+// Items lists the images, containers, volumes and build cache records the
+// cleaner's modes operate on
+func (d *ContainerCleaner) Items(ctx context.Context) ([]CacheItem, error) {
+	engine, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	du, err := engine.DiskUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []CacheItem
+	seen := make(map[string]bool)
+	add := func(item CacheItem) {
+		if !seen[item.Name] {
+			seen[item.Name] = true
+			items = append(items, item)
+		}
+	}
+	for _, mode := range d.modes {
+		switch mode {
+		case DockerModeDangling, DockerModeImages:
+			for _, img := range du.Images {
+				if mode == DockerModeDangling && !img.IsDangling() {
+					continue
+				}
+				add(CacheItem{Name: "image " + imageTarget(img), Size: img.Size - max(img.SharedSize, 0)})
+			}
+		case DockerModeContainers:
+			for _, c := range du.Containers {
+				name := c.ID
+				if len(c.Names) > 0 {
+					name = strings.TrimPrefix(c.Names[0], "/")
+				}
+				add(CacheItem{Name: "container " + name, Size: c.SizeRw})
+			}
+		case DockerModeVolumes:
+			for _, v := range du.Volumes {
+				if v.UsageData != nil && v.UsageData.Size >= 0 {
+					add(CacheItem{Name: "volume " + v.Name, Size: v.UsageData.Size})
+				}
+			}
+		case DockerModeBuildCache:
+			for _, b := range du.BuildCache {
+				name := "build cache " + b.ID
+				if b.Description != "" {
+					name += " (" + b.Description + ")"
+				}
+				item := CacheItem{Name: name, Size: b.Size}
+				if b.LastUsedAt != nil {
+					item.LastUsed = *b.LastUsedAt
+				}
+				add(item)
+			}
+		}
+	}
+	return items, nil
+}
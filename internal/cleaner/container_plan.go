@@ -0,0 +1,246 @@
+package cleaner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// DockerProtection lists images and volumes that must never be removed
+type DockerProtection struct {
+	// Labels protects items carrying one of these labels, given as "key" or "key=value"
+	Labels []string
+	// Names protects items whose name matches one of these glob patterns, e.g. "postgres*"
+	Names []string
+	// Repositories protects every tag of these image repositories
+	Repositories []string
+	// KeepRecent keeps the N most recently created images of each repository
+	KeepRecent int
+}
+
+// labelReason returns why the labels protect an item, or "" if they don't
+func (p DockerProtection) labelReason(labels map[string]string) string {
+	for _, l := range p.Labels {
+		key, value, hasValue := strings.Cut(l, "=")
+		v, ok := labels[key]
+		if ok && (!hasValue || v == value) {
+			return fmt.Sprintf("protected by label %s", l)
+		}
+	}
+	return ""
+}
+
+// nameReason returns why one of the names protects an item, or "" if none does
+func (p DockerProtection) nameReason(names ...string) string {
+	for _, pattern := range p.Names {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return fmt.Sprintf("protected by name pattern %s", pattern)
+			}
+		}
+	}
+	return ""
+}
+
+// repositoryReason returns why the repository is protected, or "" if it isn't
+func (p DockerProtection) repositoryReason(repo string) string {
+	for _, r := range p.Repositories {
+		if r == repo {
+			return fmt.Sprintf("protected repository %s", repo)
+		}
+	}
+	return ""
+}
+
+// splitRepoTag splits "registry:5000/repo:tag" into repository and tag
+func splitRepoTag(ref string) (string, string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref, "latest"
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// imageTags returns the usable tags of an image
+func imageTags(img docker.ImageSummary) []string {
+	var tags []string
+	for _, t := range img.RepoTags {
+		if t != "<none>:<none>" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// recentImages returns the IDs of the N most recently created images of each repository
+func recentImages(images []docker.ImageSummary, n int) map[string]string {
+	recent := make(map[string]string)
+	if n <= 0 {
+		return recent
+	}
+
+	byRepo := make(map[string][]docker.ImageSummary)
+	for _, img := range images {
+		seen := make(map[string]bool)
+		for _, tag := range imageTags(img) {
+			repo, _ := splitRepoTag(tag)
+			if !seen[repo] {
+				seen[repo] = true
+				byRepo[repo] = append(byRepo[repo], img)
+			}
+		}
+	}
+
+	for repo, imgs := range byRepo {
+		sort.Slice(imgs, func(i, j int) bool { return imgs[i].Created > imgs[j].Created })
+		for i := 0; i < len(imgs) && i < n; i++ {
+			if _, ok := recent[imgs[i].ID]; !ok {
+				recent[imgs[i].ID] = fmt.Sprintf("one of the %d most recent images of %s", n, repo)
+			}
+		}
+	}
+	return recent
+}
+
+// imageTarget returns a display name for an image
+func imageTarget(img docker.ImageSummary) string {
+	if tags := imageTags(img); len(tags) > 0 {
+		return strings.Join(tags, ", ")
+	}
+	id := strings.TrimPrefix(img.ID, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return "<none> " + id
+}
+
+// planImages decides which unused images the dangling or images mode would
+// remove. The returned images and plan items correspond index by index.
+func (d *ContainerCleaner) planImages(du *docker.DiskUsage, mode string) ([]docker.ImageSummary, []PlanItem) {
+	protect := d.options.Protect
+	recent := recentImages(du.Images, protect.KeepRecent)
+	cutoff := time.Now().Add(-d.options.ImageAge).Unix()
+
+	var images []docker.ImageSummary
+	var items []PlanItem
+	for _, img := range du.Images {
+		if img.Containers > 0 {
+			continue
+		}
+		if mode == DockerModeDangling && !img.IsDangling() {
+			continue
+		}
+
+		item := PlanItem{
+			Target: imageTarget(img),
+			Size:   img.Size - max(img.SharedSize, 0),
+			Remove: true,
+			Reason: "unused image",
+		}
+		if img.IsDangling() {
+			item.Reason = "dangling image"
+		}
+
+		tags := imageTags(img)
+		var repos []string
+		for _, tag := range tags {
+			repo, _ := splitRepoTag(tag)
+			repos = append(repos, repo)
+		}
+
+		reason := protect.labelReason(img.Labels)
+		if reason == "" {
+			reason = protect.nameReason(append(tags, repos...)...)
+		}
+		for _, repo := range repos {
+			if reason == "" {
+				reason = protect.repositoryReason(repo)
+			}
+		}
+		if reason == "" {
+			reason = recent[img.ID]
+		}
+		if reason == "" && mode == DockerModeImages && d.options.ImageAge > 0 && img.Created > cutoff {
+			reason = fmt.Sprintf("created within the last %s", strings.TrimSuffix(d.options.ImageAge.String(), "0m0s"))
+		}
+
+		if reason != "" {
+			item.Remove = false
+			item.Reason = reason
+		}
+		images = append(images, img)
+		items = append(items, item)
+	}
+	return images, items
+}
+
+// planVolumes decides which unused volumes the volumes mode would remove
+func (d *ContainerCleaner) planVolumes(du *docker.DiskUsage) []PlanItem {
+	protect := d.options.Protect
+
+	var items []PlanItem
+	for _, v := range du.Volumes {
+		if v.UsageData == nil || v.UsageData.RefCount > 0 {
+			continue
+		}
+		item := PlanItem{
+			Target: v.Name,
+			Size:   v.UsageData.Size,
+			Remove: true,
+			Reason: "unused volume",
+		}
+		reason := protect.labelReason(v.Labels)
+		if reason == "" {
+			reason = protect.nameReason(v.Name)
+		}
+		if reason != "" {
+			item.Remove = false
+			item.Reason = reason
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// planMode returns the plan items for a single mode
+func (d *ContainerCleaner) planMode(du *docker.DiskUsage, mode string) []PlanItem {
+	switch mode {
+	case DockerModeDangling, DockerModeImages:
+		_, items := d.planImages(du, mode)
+		return items
+	case DockerModeVolumes:
+		return d.planVolumes(du)
+	case DockerModeContainers:
+		var items []PlanItem
+		for _, c := range du.Containers {
+			if c.State == "running" {
+				continue
+			}
+			name := c.ID
+			if len(c.Names) > 0 {
+				name = strings.TrimPrefix(c.Names[0], "/")
+			}
+			items = append(items, PlanItem{Target: name, Size: c.SizeRw, Remove: true, Reason: c.State + " container"})
+		}
+		return items
+	case DockerModeBuildCache:
+		var size int64
+		for _, b := range du.BuildCache {
+			if !b.InUse {
+				size += b.Size
+			}
+		}
+		item := PlanItem{Target: "build cache", Size: max(size-d.options.KeepStorage, 0), Remove: true, Reason: "unused build cache"}
+		if d.options.KeepStorage > 0 {
+			item.Reason += fmt.Sprintf(" beyond the %s kept", FormatSize(d.options.KeepStorage))
+		}
+		return []PlanItem{item}
+	case DockerModeNetworks:
+		return []PlanItem{{Target: "unused networks", Size: -1, Remove: true, Reason: "not used by any container"}}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// fakeEngine serves a fixed disk usage and records removals
+type fakeEngine struct {
+	du      *docker.DiskUsage
+	removed []string
+}
+
+func (e *fakeEngine) Host() string                   { return "fake" }
+func (e *fakeEngine) Ping(ctx context.Context) error { return nil }
+func (e *fakeEngine) DiskUsage(ctx context.Context) (*docker.DiskUsage, error) {
+	return e.du, nil
+}
+func (e *fakeEngine) PruneContainers(ctx context.Context, _ docker.Filters) (*docker.PruneReport, error) {
+	return &docker.PruneReport{}, nil
+}
+func (e *fakeEngine) PruneNetworks(ctx context.Context, _ docker.Filters) (*docker.PruneReport, error) {
+	return &docker.PruneReport{}, nil
+}
+func (e *fakeEngine) PruneBuildCache(ctx context.Context, _ bool, _ int64, _ docker.Filters) (*docker.PruneReport, error) {
+	return &docker.PruneReport{}, nil
+}
+func (e *fakeEngine) RemoveImage(ctx context.Context, ref string) error {
+	e.removed = append(e.removed, "image "+ref)
+	return nil
+}
+func (e *fakeEngine) RemoveVolume(ctx context.Context, name string) error {
+	e.removed = append(e.removed, "volume "+name)
+	return nil
+}
+
+func hoursAgo(h int) int64 {
+	return time.Now().Add(-time.Duration(h) * time.Hour).Unix()
+}
+
+func image(id string, created int64, labels map[string]string, tags ...string) docker.ImageSummary {
+	if len(tags) == 0 {
+		tags = []string{"<none>:<none>"}
+	}
+	return docker.ImageSummary{ID: id, RepoTags: tags, Created: created, Size: 100, Labels: labels}
+}
+
+func newPlanCleaner(protect DockerProtection, imageAge time.Duration, engine ContainerEngine) *ContainerCleaner {
+	return NewContainerCleaner("docker", "Docker", func() (ContainerEngine, error) { return engine, nil },
+		[]string{DockerModeImages, DockerModeVolumes}, DockerOptions{ImageAge: imageAge, Protect: protect})
+}
+
+// kept maps each plan target to its reason, prefixed with "remove: " for
+// the items that would be removed
+func kept(items []PlanItem) map[string]string {
+	reasons := make(map[string]string)
+	for _, item := range items {
+		if item.Remove {
+			reasons[item.Target] = "remove: " + item.Reason
+		} else {
+			reasons[item.Target] = item.Reason
+		}
+	}
+	return reasons
+}
+
+func TestPlanImages(t *testing.T) {
+	old := hoursAgo(100)
+	tests := []struct {
+		name     string
+		protect  DockerProtection
+		imageAge time.Duration
+		mode     string
+		images   []docker.ImageSummary
+		want     map[string]string
+	}{
+		{
+			name:   "unused and dangling",
+			mode:   DockerModeImages,
+			images: []docker.ImageSummary{image("sha256:aaaaaaaaaaaaaaaa", old, nil, "app:v1"), image("sha256:bbbbbbbbbbbbbbbb", old, nil)},
+			want:   map[string]string{"app:v1": "remove: unused image", "<none> bbbbbbbbbbbb": "remove: dangling image"},
+		},
+		{
+			name:   "dangling mode leaves tagged images out",
+			mode:   DockerModeDangling,
+			images: []docker.ImageSummary{image("a", old, nil, "app:v1"), image("b", old, nil)},
+			want:   map[string]string{"<none> b": "remove: dangling image"},
+		},
+		{
+			name:    "label without value",
+			protect: DockerProtection{Labels: []string{"keep"}},
+			mode:    DockerModeImages,
+			images:  []docker.ImageSummary{image("a", old, map[string]string{"keep": "no"}, "app:v1"), image("b", old, nil, "app:v2")},
+			want:    map[string]string{"app:v1": "protected by label keep", "app:v2": "remove: unused image"},
+		},
+		{
+			name:    "label with value",
+			protect: DockerProtection{Labels: []string{"keep=true"}},
+			mode:    DockerModeImages,
+			images:  []docker.ImageSummary{image("a", old, map[string]string{"keep": "true"}, "app:v1"), image("b", old, map[string]string{"keep": "false"}, "app:v2")},
+			want:    map[string]string{"app:v1": "protected by label keep=true", "app:v2": "remove: unused image"},
+		},
+		{
+			name:    "name glob on tag or repository",
+			protect: DockerProtection{Names: []string{"postgres*"}},
+			mode:    DockerModeImages,
+			images:  []docker.ImageSummary{image("a", old, nil, "postgres:16"), image("b", old, nil, "mysql:8")},
+			want:    map[string]string{"postgres:16": "protected by name pattern postgres*", "mysql:8": "remove: unused image"},
+		},
+		{
+			name:    "repository",
+			protect: DockerProtection{Repositories: []string{"registry:5000/app"}},
+			mode:    DockerModeImages,
+			images:  []docker.ImageSummary{image("a", old, nil, "registry:5000/app:v1"), image("b", old, nil, "registry:5000/other:v1")},
+			want:    map[string]string{"registry:5000/app:v1": "protected repository registry:5000/app", "registry:5000/other:v1": "remove: unused image"},
+		},
+		{
+			name:    "keep recent per repository",
+			protect: DockerProtection{KeepRecent: 1},
+			mode:    DockerModeImages,
+			images: []docker.ImageSummary{
+				image("a1", hoursAgo(300), nil, "app:v1"),
+				image("a2", hoursAgo(200), nil, "app:v2"),
+				image("b1", hoursAgo(400), nil, "web:v1"),
+			},
+			want: map[string]string{
+				"app:v1": "remove: unused image",
+				"app:v2": "one of the 1 most recent images of app",
+				"web:v1": "one of the 1 most recent images of web",
+			},
+		},
+		{
+			name:     "age cutoff only in images mode",
+			imageAge: 24 * time.Hour,
+			mode:     DockerModeImages,
+			images:   []docker.ImageSummary{image("a", hoursAgo(1), nil, "app:new"), image("b", hoursAgo(48), nil, "app:old")},
+			want:     map[string]string{"app:new": "created within the last 24h", "app:old": "remove: unused image"},
+		},
+		{
+			name:     "age cutoff does not apply to dangling mode",
+			imageAge: 24 * time.Hour,
+			mode:     DockerModeDangling,
+			images:   []docker.ImageSummary{image("a", hoursAgo(1), nil)},
+			want:     map[string]string{"<none> a": "remove: dangling image"},
+		},
+		{
+			name: "precedence: label, name, repository, recent, age",
+			protect: DockerProtection{
+				Labels:       []string{"keep"},
+				Names:        []string{"app*"},
+				Repositories: []string{"app", "web"},
+				KeepRecent:   1,
+			},
+			imageAge: 24 * time.Hour,
+			mode:     DockerModeImages,
+			images: []docker.ImageSummary{
+				image("a", hoursAgo(1), map[string]string{"keep": ""}, "app:label"),
+				image("b", hoursAgo(1), nil, "app:name"),
+				image("c", hoursAgo(1), nil, "web:repo"),
+				image("d", hoursAgo(1), nil, "db:recent"),
+				image("e", hoursAgo(2), nil, "db:age"),
+			},
+			want: map[string]string{
+				"app:label": "protected by label keep",
+				"app:name":  "protected by name pattern app*",
+				"web:repo":  "protected repository web",
+				"db:recent": "one of the 1 most recent images of db",
+				"db:age":    "created within the last 24h",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An image used by a container is never planned
+			du := &docker.DiskUsage{Images: append(slices.Clone(tt.images), docker.ImageSummary{ID: "used", RepoTags: []string{"used:v1"}, Containers: 1})}
+			c := newPlanCleaner(tt.protect, tt.imageAge, nil)
+			images, items := c.planImages(du, tt.mode)
+			if len(images) != len(items) {
+				t.Fatalf("%d images for %d items", len(images), len(items))
+			}
+			got := kept(items)
+			if len(got) != len(tt.want) {
+				t.Errorf("plan = %v, want %v", got, tt.want)
+			}
+			for target, reason := range tt.want {
+				if got[target] != reason {
+					t.Errorf("%s: got %q, want %q", target, got[target], reason)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanVolumes(t *testing.T) {
+	volume := func(name string, refs int64, labels map[string]string) docker.Volume {
+		return docker.Volume{Name: name, Labels: labels, UsageData: &docker.VolumeUsage{Size: 10, RefCount: refs}}
+	}
+	du := &docker.DiskUsage{Volumes: []docker.Volume{
+		volume("scratch", 0, nil),
+		volume("in-use", 1, nil),
+		volume("labelled", 0, map[string]string{"keep": "true"}),
+		volume("wrong-value", 0, map[string]string{"keep": "false"}),
+		volume("postgres-data", 0, nil),
+		volume("both", 0, map[string]string{"keep": "true"}),
+		{Name: "unknown-usage"},
+	}}
+	c := newPlanCleaner(DockerProtection{Labels: []string{"keep=true"}, Names: []string{"postgres*", "both"}}, 0, nil)
+	want := map[string]string{
+		"scratch":       "remove: unused volume",
+		"labelled":      "protected by label keep=true",
+		"wrong-value":   "remove: unused volume",
+		"postgres-data": "protected by name pattern postgres*",
+		"both":          "protected by label keep=true",
+	}
+	got := kept(c.planVolumes(du))
+	if len(got) != len(want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+	for target, reason := range want {
+		if got[target] != reason {
+			t.Errorf("%s: got %q, want %q", target, got[target], reason)
+		}
+	}
+}
+
+func TestRemovePlannedKeepsProtected(t *testing.T) {
+	engine := &fakeEngine{du: &docker.DiskUsage{
+		Images: []docker.ImageSummary{
+			image("sha256:aaaaaaaaaaaaaaaa", hoursAgo(100), map[string]string{"keep": "true"}, "app:v1"),
+			image("sha256:bbbbbbbbbbbbbbbb", hoursAgo(100), nil, "postgres:16"),
+			image("sha256:cccccccccccccccc", hoursAgo(100), nil, "tmp:v1"),
+			image("sha256:dddddddddddddddd", hoursAgo(100), nil),
+		},
+		Volumes: []docker.Volume{
+			{Name: "db", Labels: map[string]string{"keep": "true"}, UsageData: &docker.VolumeUsage{}},
+			{Name: "postgres-data", UsageData: &docker.VolumeUsage{}},
+			{Name: "scratch", UsageData: &docker.VolumeUsage{}},
+		},
+	}}
+	c := newPlanCleaner(DockerProtection{Labels: []string{"keep"}, Names: []string{"postgres*"}}, 24*time.Hour, engine)
+	for _, mode := range []string{DockerModeImages, DockerModeVolumes} {
+		if _, err := c.removePlanned(context.Background(), engine, mode); err != nil {
+			t.Fatalf("removePlanned(%s): %v", mode, err)
+		}
+	}
+	want := []string{"image tmp:v1", "image sha256:dddddddddddddddd", "volume scratch"}
+	if !slices.Equal(engine.removed, want) {
+		t.Errorf("removed %v, want %v", engine.removed, want)
+	}
+}
@@ -0,0 +1,283 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// CustomOptions declares a user-defined cleaner
+type CustomOptions struct {
+	// Name is the key used to select the cleaner
+	Name string
+	// Description is shown in the menu and report, defaults to Name
+	Description string
+	// Icon is shown in the menu, defaults to 🧩
+	Icon string
+	// Paths are the paths or glob patterns to clean. Matching directories
+	// have their contents removed, matching files are removed.
+	Paths []string
+	// Root is the directory every matched path must resolve inside of
+	Root string
+	// MinAge only removes entries not modified for at least this long
+	MinAge time.Duration
+	// Pre is a shell command run before cleaning, aborting it if it fails
+	Pre string
+	// Post is a shell command run after cleaning
+	Post string
+	// InAll marks the cleaner as selected by "all"
+	InAll bool
+	// InUse tells when the cache is in use and must be left alone
+	InUse InUseOptions
+}
+
+// ExpandPath expands a leading ~ and environment variables in path
+func ExpandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// withinRoot reports whether path is root or below it. Both must be clean
+// absolute paths.
+func withinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// guardPath returns a safety violation unless real, the resolved form of
+// path, is root or below it
+func guardPath(root, path, real string) error {
+	if !withinRoot(root, real) {
+		return errors.NewErrSafetyViolation(path, fmt.Sprintf("resolves to %s, outside root %s", real, root))
+	}
+	return nil
+}
+
+// Validate checks the options without touching the file system
+func (o CustomOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("custom cleaner has no name")
+	}
+	if !ValidKey(o.Name) {
+		return fmt.Errorf("custom cleaner %q: name must be lowercase without spaces or commas, and not a number or one of all, report and exit", o.Name)
+	}
+	if len(o.Paths) == 0 {
+		return fmt.Errorf("custom cleaner %s: no paths", o.Name)
+	}
+	if o.MinAge < 0 {
+		return fmt.Errorf("custom cleaner %s: min_age must not be negative", o.Name)
+	}
+
+	root := filepath.Clean(ExpandPath(o.Root))
+	if o.Root == "" || !filepath.IsAbs(root) {
+		return fmt.Errorf("custom cleaner %s: root must be an absolute path", o.Name)
+	}
+	if filepath.Dir(root) == root {
+		return fmt.Errorf("custom cleaner %s: root must not be a file system root", o.Name)
+	}
+	for _, pattern := range o.Paths {
+		path := filepath.Clean(ExpandPath(pattern))
+		if _, err := filepath.Match(path, ""); err != nil {
+			return fmt.Errorf("custom cleaner %s: invalid pattern %q", o.Name, pattern)
+		}
+		if !filepath.IsAbs(path) || !withinRoot(root, path) {
+			return errors.NewErrSafetyViolation(pattern, fmt.Sprintf("custom cleaner %s: not inside root %s", o.Name, o.Root))
+		}
+	}
+	for _, pattern := range slices.Concat(o.InUse.Processes, o.InUse.LockFiles) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("custom cleaner %s: invalid in_use pattern %q", o.Name, pattern)
+		}
+	}
+	return nil
+}
+
+// CustomCleaner cleans paths declared in the configuration file
+type CustomCleaner struct {
+	*BaseCleaner
+	options CustomOptions
+}
+
+// NewCustomCleaner creates a new CustomCleaner
+func NewCustomCleaner(options CustomOptions) *CustomCleaner {
+	return &CustomCleaner{
+		BaseCleaner: NewBaseCleaner(options.Name),
+		options:     options,
+	}
+}
+
+// matches expands the configured patterns, refusing any match that resolves
+// outside the root
+func (c *CustomCleaner) matches() ([]string, error) {
+	if err := c.options.Validate(); err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(filepath.Clean(ExpandPath(c.options.Root)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root %s: %w", c.options.Root, err)
+	}
+
+	var matches []string
+	for _, pattern := range c.options.Paths {
+		found, err := filepath.Glob(filepath.Clean(ExpandPath(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, path := range found {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+			}
+			if err := guardPath(root, path, real); err != nil {
+				return nil, err
+			}
+			matches = append(matches, real)
+		}
+	}
+	return matches, nil
+}
+
+// CachePaths returns the cleaner's root, the directory everything it
+// removes must be in
+func (c *CustomCleaner) CachePaths() []string {
+	return []string{filepath.Clean(ExpandPath(c.options.Root))}
+}
+
+// items lists what Clean would remove and keep
+func (c *CustomCleaner) items() ([]PlanItem, error) {
+	matches, err := c.matches()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PlanItem
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			items = append(items, pathItem(path, c.options.MinAge))
+			continue
+		}
+		found, err := dirItems(path, c.options.MinAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+// Available checks the options and that the root exists
+func (c *CustomCleaner) Available(ctx context.Context) error {
+	if err := c.options.Validate(); err != nil {
+		return err
+	}
+	if exists, _ := CheckPathExists(ExpandPath(c.options.Root)); !exists {
+		return fmt.Errorf("root %s does not exist", c.options.Root)
+	}
+	return nil
+}
+
+// InUse reports whether one of the configured processes is running or
+// lock files is held
+func (c *CustomCleaner) InUse(ctx context.Context) error {
+	return c.options.InUse.Check(ctx, c.GetName())
+}
+
+// Clean runs the pre command, removes the matched paths and runs the post
+// command
+func (c *CustomCleaner) Clean(ctx context.Context) error {
+	if err := c.run(ctx, "pre", c.options.Pre); err != nil {
+		return err
+	}
+
+	items, err := c.items()
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		printf(ctx, "[%s] Nothing matched, skipping.\n", c.GetName())
+	} else if err := removeItems(ctx, c.GetName(), items); err != nil {
+		return err
+	}
+
+	return c.run(ctx, "post", c.options.Post)
+}
+
+// run runs a configured hook through the system shell
+func (c *CustomCleaner) run(ctx context.Context, stage, command string) error {
+	if command == "" {
+		return nil
+	}
+	printf(ctx, "[%s] Running %s command: %s\n", c.GetName(), stage, command)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdout = Output(ctx)
+	cmd.Stderr = errorOutput(ctx)
+	err := cmd.Run()
+	audit.FromContext(ctx).Command(cmd, err)
+	if err != nil {
+		return fmt.Errorf("%s command failed: %w", stage, err)
+	}
+	return nil
+}
+
+// Plan returns the entries Clean would remove and the ones it would keep.
+// Commands are not run.
+func (c *CustomCleaner) Plan(ctx context.Context) (*Plan, error) {
+	items, err := c.items()
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if size, err := MeasurePath(items[i].Target); err == nil {
+			items[i].Size = size
+		}
+	}
+	return &Plan{CleanerName: c.GetName(), Items: items}, nil
+}
+
+// GetSize returns the combined size of the matched paths
+func (c *CustomCleaner) GetSize(ctx context.Context) (string, error) {
+	matches, err := c.matches()
+	if err != nil {
+		return "Error", err
+	}
+	return sizeOfPaths(matches)
+}
+
+// Measure returns the combined size of the matched paths in bytes
+func (c *CustomCleaner) Measure(ctx context.Context) (int64, error) {
+	matches, err := c.matches()
+	if err != nil {
+		return -1, err
+	}
+	return measurePaths(matches)
+}
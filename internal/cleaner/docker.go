@@ -1,78 +1,88 @@
 package cleaner
 
 import (
-	"context"
-	"fmt"
-	"os"
-	"os/exec"
-	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/docker"
 )
 
-// DockerCleaner handles cleaning of Docker cache
-type DockerCleaner struct {
-	*BaseCleaner
-}
+// Docker cleanup modes, each selectable as its own cleaner
+const (
+	DockerModeDangling   = "dangling"
+	DockerModeImages     = "images"
+	DockerModeContainers = "containers"
+	DockerModeBuildCache = "buildcache"
+	DockerModeNetworks   = "networks"
+	DockerModeVolumes    = "volumes"
+)
 
-// NewDockerCleaner creates a new DockerCleaner
-func NewDockerCleaner() *DockerCleaner {
-	return &DockerCleaner{
-		BaseCleaner: NewBaseCleaner("docker"),
-	}
+// DockerModes lists every Docker cleanup mode in execution order
+var DockerModes = []string{
+	DockerModeContainers,
+	DockerModeDangling,
+	DockerModeImages,
+	DockerModeNetworks,
+	DockerModeBuildCache,
+	DockerModeVolumes,
 }
 
-// Clean performs the Docker cache cleaning operation
-func (d *DockerCleaner) Clean(ctx context.Context) error {
-	fmt.Println("[docker] Running Docker cleanup commands...")
-	if _, err := exec.LookPath("docker"); err != nil {
-		fmt.Println("[docker] Docker not found in PATH.")
-		return fmt.Errorf("docker not found in PATH")
-	}
-
-	// Check if Docker daemon is running
-	cmd := exec.CommandContext(ctx, "docker", "info")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker daemon is not running")
-	}
+// DefaultDockerModes are run by the plain "docker" cleaner. Volumes are
+// deliberately left out because they may hold data, not just cache.
+var DefaultDockerModes = []string{
+	DockerModeContainers,
+	DockerModeImages,
+	DockerModeNetworks,
+	DockerModeBuildCache,
+}
 
-	commands := []struct {
-		cmd  []string
-		desc string
-	}{
-		{[]string{"docker", "system", "prune", "--all", "-f"}, "system prune"},
-		{[]string{"docker", "volume", "prune", "-f"}, "volume prune"},
-		{[]string{"docker", "builder", "prune", "--all", "-f"}, "builder prune"},
-	}
+// DockerOptions tunes the Docker cleanup modes
+type DockerOptions struct {
+	// ImageAge only prunes unused images created longer ago than this
+	ImageAge time.Duration
+	// KeepStorage is the amount of build cache in bytes to keep
+	KeepStorage int64
+	// Protect lists images and volumes that are never removed
+	Protect DockerProtection
+}
 
-	for _, c := range commands {
-		cmd := exec.CommandContext(ctx, c.cmd[0], c.cmd[1:]...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("[docker] Failed to run %s: %v\n", c.desc, err)
-			return fmt.Errorf("failed to run %s: %v", c.desc, err)
-		}
+// DefaultDockerOptions returns the default Docker cleanup options
+func DefaultDockerOptions() DockerOptions {
+	return DockerOptions{
+		ImageAge: 24 * time.Hour,
 	}
+}
 
-	fmt.Println("[docker] Docker cleanup completed.")
-	return nil
+// NewDockerCleaner creates a cleaner running the default modes against the Docker Engine API
+func NewDockerCleaner(options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner("docker", "Docker", newDockerEngine, DefaultDockerModes, options)
 }
 
-// GetSize returns the size of Docker cache
-func (d *DockerCleaner) GetSize(ctx context.Context) (string, error) {
-	if _, err := exec.LookPath("docker"); err != nil {
-		return "Not installed", nil
-	}
+// NewDockerModeCleaner creates a Docker cleaner running a single mode
+func NewDockerModeCleaner(mode string, options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner("docker-"+mode, "Docker", newDockerEngine, []string{mode}, options)
+}
 
-	// Check if Docker daemon is running
-	cmd := exec.CommandContext(ctx, "docker", "info")
-	if err := cmd.Run(); err != nil {
-		return "Docker not running", nil
-	}
+// newDockerEngine connects to DOCKER_HOST or the default Docker socket
+func newDockerEngine() (ContainerEngine, error) {
+	return docker.NewClient()
+}
 
-	cmd = exec.CommandContext(ctx, "docker", "system", "df", "--format", "{{.Size}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return "Error getting size", nil
+// DockerModeDescription returns a human-readable description of a mode
+func DockerModeDescription(mode string) string {
+	switch mode {
+	case DockerModeContainers:
+		return "stopped containers"
+	case DockerModeDangling:
+		return "dangling images"
+	case DockerModeImages:
+		return "unused images"
+	case DockerModeNetworks:
+		return "unused networks"
+	case DockerModeBuildCache:
+		return "build cache"
+	case DockerModeVolumes:
+		return "unused volumes"
+	default:
+		return mode
 	}
-	return strings.TrimSpace(string(output)), nil
 }
@@ -0,0 +1,99 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// DiskNode is a file or directory measured by ScanTree
+type DiskNode struct {
+	Name string
+	Path string
+	// Size is the size of the file, or of everything below the directory
+	Size int64
+	// Modified is the newest modification time of the node or anything below it
+	Modified time.Time
+	IsDir    bool
+	// Children are sorted by size, largest first
+	Children []*DiskNode
+	// Err is set if the node could not be read completely
+	Err error
+}
+
+// ScanTree measures path and everything below it, counting file sizes like
+// GetDirSize. Symbolic links are listed but not followed.
+func ScanTree(ctx context.Context, path string) (*DiskNode, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	return scanNode(ctx, path, info)
+}
+
+func scanNode(ctx context.Context, path string, info os.FileInfo) (*DiskNode, error) {
+	node := &DiskNode{Name: info.Name(), Path: path, Modified: info.ModTime(), IsDir: info.IsDir()}
+	if !node.IsDir {
+		node.Size = info.Size()
+		return node, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		node.Err = err
+	}
+	for _, entry := range entries {
+		childInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		child, err := scanNode(ctx, filepath.Join(path, entry.Name()), childInfo)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+		node.Size += child.Size
+		if child.Modified.After(node.Modified) {
+			node.Modified = child.Modified
+		}
+	}
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return node.Children[i].Size > node.Children[j].Size
+	})
+	return node, nil
+}
+
+// RemoveWithin removes path after checking, like custom cleaners do, that it
+// is below root once symbolic links are resolved. The root itself and
+// anything in a file system root are refused.
+func RemoveWithin(root, path string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve root %s: %w", root, err)
+	}
+	if filepath.Dir(realRoot) == realRoot {
+		return errors.NewErrSafetyViolation(path, "removing from a file system root is not allowed")
+	}
+
+	// Resolve the parent only: RemoveAll removes a link, not its target
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	real := filepath.Join(parent, filepath.Base(path))
+	if real == realRoot {
+		return errors.NewErrSafetyViolation(path, "it is the root being explored")
+	}
+	if err := guardPath(realRoot, path, real); err != nil {
+		return err
+	}
+	return os.RemoveAll(real)
+}
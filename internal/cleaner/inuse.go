@@ -0,0 +1,132 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// staleLockAge is how long after its last change a tool's lock file is
+// assumed left behind by a process that crashed, rather than held
+const staleLockAge = 10 * time.Minute
+
+// InUseChecker is implemented by cleaners that can tell whether the tool
+// owning their cache is using it, so it is not cleaned from under it
+type InUseChecker interface {
+	// InUse returns *errors.ErrInUse if the cache is in use, nil otherwise
+	InUse(ctx context.Context) error
+}
+
+// CheckInUse returns *errors.ErrInUse if c's cache is in use. Cleaners that
+// do not implement InUseChecker are assumed idle.
+func CheckInUse(ctx context.Context, c Cleaner) error {
+	checker, ok := c.(InUseChecker)
+	if !ok {
+		return nil
+	}
+	return checker.InUse(ctx)
+}
+
+// InUseOptions describes how to tell that a cache is in use
+type InUseOptions struct {
+	// Processes are names or glob patterns of executables that use the
+	// cache, e.g. "npm". Scripts run by node are matched by their file name
+	// without extension, e.g. "npm-cli".
+	Processes []string
+	// LockFiles are glob patterns of lock files the tools hold while using
+	// the cache. Lock files not changed for staleLockAge are ignored.
+	LockFiles []string
+}
+
+// Check returns *errors.ErrInUse for cleanerName if a lock file is held or
+// one of the processes is running
+func (o InUseOptions) Check(ctx context.Context, cleanerName string) error {
+	for _, pattern := range o.LockFiles {
+		matches, _ := filepath.Glob(ExpandPath(pattern))
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err == nil && time.Since(info.ModTime()) < staleLockAge {
+				return errors.NewErrInUse(cleanerName, "lock file "+path+" is held")
+			}
+		}
+	}
+
+	if len(o.Processes) == 0 {
+		return nil
+	}
+	// Processes that cannot be listed are not known to be running; lock
+	// files are still checked
+	procs, err := processes(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, p := range procs {
+		for _, name := range p.Names {
+			for _, pattern := range o.Processes {
+				if matched, _ := filepath.Match(strings.ToLower(pattern), name); matched {
+					return errors.NewErrInUse(cleanerName, fmt.Sprintf("%s is running (pid %d)", name, p.PID))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// process is a running process
+type process struct {
+	PID int
+	// Names are the lower-case executable name without extension and, for
+	// programs run by an interpreter or the JVM, the script, jar or main
+	// class name, e.g. "node" and "npm-cli", or "java" and "gradledaemon"
+	Names []string
+}
+
+// interpreters run the script given as their first argument
+var interpreters = map[string]bool{
+	"node": true, "nodejs": true, "sh": true, "bash": true, "dash": true, "zsh": true,
+	"python": true, "python3": true, "ruby": true, "perl": true,
+}
+
+// processNames returns the names a process started with args is known by
+func processNames(args []string) []string {
+	if len(args) == 0 || args[0] == "" {
+		return nil
+	}
+	name := func(arg string) string {
+		// Command lines from other platforms may use either separator
+		base := strings.ToLower(arg[strings.LastIndexAny(arg, `/\`)+1:])
+		for _, ext := range []string{".exe", ".js", ".cjs", ".mjs", ".sh", ".py", ".jar"} {
+			base = strings.TrimSuffix(base, ext)
+		}
+		return base
+	}
+
+	names := []string{name(args[0])}
+	switch {
+	case interpreters[names[0]]:
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				names = append(names, name(arg))
+				break
+			}
+		}
+	case names[0] == "java" || names[0] == "javaw":
+		for i := 1; i < len(args); i++ {
+			switch arg := args[i]; {
+			case arg == "-jar" && i+1 < len(args):
+				return append(names, name(args[i+1]))
+			case arg == "-cp" || arg == "-classpath" || arg == "--class-path":
+				i++
+			case !strings.HasPrefix(arg, "-"):
+				// The main class, e.g. org.gradle.launcher.daemon.bootstrap.GradleDaemon
+				return append(names, strings.ToLower(arg[strings.LastIndex(arg, ".")+1:]))
+			}
+		}
+	}
+	return names
+}
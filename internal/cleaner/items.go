@@ -0,0 +1,200 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CacheItem is an individual entry in a cache, such as a package, an image
+// or a directory
+type CacheItem struct {
+	Name string
+	Size int64
+	// LastUsed is when the entry was last read or written, zero if unknown
+	LastUsed time.Time
+}
+
+// Lister is implemented by cleaners that can list the individual entries
+// in their cache
+type Lister interface {
+	// Items returns the entries in the cache in no particular order
+	Items(ctx context.Context) ([]CacheItem, error)
+}
+
+// entryItem measures path and finds when anything below it was last used
+func entryItem(path string) CacheItem {
+	item := CacheItem{Name: path}
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			item.Size += info.Size()
+		}
+		for _, t := range []time.Time{accessTime(info), info.ModTime()} {
+			if t.After(item.LastUsed) {
+				item.LastUsed = t
+			}
+		}
+		return nil
+	})
+	return item
+}
+
+// dirEntryItems lists the entries directly inside each of dirs. Missing
+// directories are skipped.
+func dirEntryItems(ctx context.Context, dirs ...string) ([]CacheItem, error) {
+	var items []CacheItem
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			items = append(items, entryItem(filepath.Join(dir, entry.Name())))
+		}
+	}
+	return items, nil
+}
+
+// npmIndexEntry is a line of an npm cache index bucket
+type npmIndexEntry struct {
+	Key       string
+	Integrity string
+	Time      int64
+	Size      int64
+}
+
+// npmIndexItems lists the entries in an npm cache index (_cacache/index-v5).
+// Buckets are append-only, so later lines replace earlier ones and lines
+// without integrity mark deleted entries.
+func npmIndexItems(ctx context.Context, index string) ([]CacheItem, error) {
+	latest := make(map[string]npmIndexEntry)
+	err := filepath.Walk(index, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			_, data, ok := strings.Cut(scanner.Text(), "\t")
+			if !ok {
+				continue
+			}
+			var entry npmIndexEntry
+			if json.Unmarshal([]byte(data), &entry) != nil {
+				continue
+			}
+			if entry.Integrity == "" {
+				delete(latest, entry.Key)
+			} else {
+				latest[entry.Key] = entry
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CacheItem, 0, len(latest))
+	for key, entry := range latest {
+		items = append(items, CacheItem{
+			Name:     strings.TrimPrefix(key, "make-fetch-happen:request-cache:"),
+			Size:     entry.Size,
+			LastUsed: time.UnixMilli(entry.Time),
+		})
+	}
+	return items, nil
+}
+
+// Items lists the packages in the npm cache index, or the entries of the
+// cache directories if they have no index
+func (n *NPMCleaner) Items(ctx context.Context) ([]CacheItem, error) {
+	var items []CacheItem
+	for _, path := range n.CachePaths() {
+		var found []CacheItem
+		var err error
+		index := filepath.Join(path, "_cacache", "index-v5")
+		if exists, _ := CheckPathExists(index); exists {
+			found, err = npmIndexItems(ctx, index)
+		} else {
+			found, err = dirEntryItems(ctx, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+var yarnCacheVersion = regexp.MustCompile(`^v\d+$`)
+
+// Items lists the packages in the yarn cache. Yarn keeps them in a
+// directory per cache version, e.g. v6, inside the cache directory.
+func (y *YarnCleaner) Items(ctx context.Context) ([]CacheItem, error) {
+	var dirs []string
+	for _, path := range y.CachePaths() {
+		entries, err := os.ReadDir(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		versioned := false
+		for _, entry := range entries {
+			if entry.IsDir() && yarnCacheVersion.MatchString(entry.Name()) {
+				dirs = append(dirs, filepath.Join(path, entry.Name()))
+				versioned = true
+			}
+		}
+		if !versioned {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirEntryItems(ctx, dirs...)
+}
+
+// Items lists the entries of the directories the cleaner type operates on
+func (w *WindowsCleaner) Items(ctx context.Context) ([]CacheItem, error) {
+	return dirEntryItems(ctx, w.CachePaths()...)
+}
+
+// Items lists the entries Clean would remove or keep
+func (c *CustomCleaner) Items(ctx context.Context) ([]CacheItem, error) {
+	planned, err := c.items()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]CacheItem, 0, len(planned))
+	for _, item := range planned {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		items = append(items, entryItem(item.Target))
+	}
+	return items, nil
+}
@@ -0,0 +1,23 @@
+package cleaner
+
+import (
+	"github.com/abdorrahmani/clearance/internal/nerdctl"
+)
+
+// NewNerdctlCleaner creates a cleaner running the default modes against a
+// containerd namespace through nerdctl
+func NewNerdctlCleaner(name, namespace string, options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner(name, "containerd", newNerdctlEngine(namespace), DefaultDockerModes, options)
+}
+
+// NewNerdctlModeCleaner creates a nerdctl cleaner running a single mode
+func NewNerdctlModeCleaner(name, namespace, mode string, options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner(name, "containerd", newNerdctlEngine(namespace), []string{mode}, options)
+}
+
+// newNerdctlEngine returns a constructor for a nerdctl engine bound to namespace
+func newNerdctlEngine(namespace string) func() (ContainerEngine, error) {
+	return func() (ContainerEngine, error) {
+		return nerdctl.NewClient(namespace)
+	}
+}
@@ -6,62 +6,92 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
 )
 
 // NPMCleaner handles cleaning of npm cache
 type NPMCleaner struct {
 	*BaseCleaner
+	options PathOptions
 }
 
 // NewNPMCleaner creates a new NPMCleaner
-func NewNPMCleaner() *NPMCleaner {
+func NewNPMCleaner(options PathOptions) *NPMCleaner {
 	return &NPMCleaner{
 		BaseCleaner: NewBaseCleaner("npm"),
+		options:     options,
 	}
 }
 
+// CachePaths returns the npm cache locations
+func (n *NPMCleaner) CachePaths() []string {
+	return n.options.paths(filepath.Join(os.Getenv("LOCALAPPDATA"), "npm-cache"))
+}
+
 // Clean performs the npm cache cleaning operation
 func (n *NPMCleaner) Clean(ctx context.Context) error {
-	fmt.Println("[npm] Attempting to remove npm cache folder...")
+	if n.options.IsSet() {
+		return cleanPaths(ctx, n.GetName(), n.CachePaths(), n.options.MinAge)
+	}
+
+	printf(ctx, "[npm] Attempting to remove npm cache folder...\n")
 	npmCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "npm-cache")
-	if err := os.RemoveAll(npmCache); err == nil {
-		fmt.Println("[npm] Folder removed successfully.")
+	if err := removePath(ctx, npmCache); err == nil {
+		printf(ctx, "[npm] Folder removed successfully.\n")
 		return nil
 	} else {
-		fmt.Printf("[npm] Folder removal failed: %v\n", err)
+		printf(ctx, "[npm] Folder removal failed: %v\n", err)
 	}
 
-	fmt.Println("[npm] Fallback: running 'npm cache clean --force'...")
+	printf(ctx, "[npm] Fallback: running 'npm cache clean --force'...\n")
 	if npmPath, err := exec.LookPath("npm"); err == nil {
 		cmd := exec.CommandContext(ctx, npmPath, "cache", "clean", "--force")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err == nil {
-			fmt.Println("[npm] npm CLI cache clean succeeded.")
+		cmd.Stdout = Output(ctx)
+		cmd.Stderr = errorOutput(ctx)
+		err := cmd.Run()
+		audit.FromContext(ctx).Command(cmd, err)
+		if err == nil {
+			printf(ctx, "[npm] npm CLI cache clean succeeded.\n")
 			return nil
 		} else {
-			fmt.Printf("[npm] npm CLI cache clean failed: %v\n", err)
+			printf(ctx, "[npm] npm CLI cache clean failed: %v\n", err)
 		}
 	} else {
-		fmt.Println("[npm] npm not found in PATH.")
+		printf(ctx, "[npm] npm not found in PATH.\n")
 	}
 
 	return fmt.Errorf("failed to clean npm cache using both direct deletion and npm CLI")
 }
 
-// GetSize returns the size of npm cache
-func (n *NPMCleaner) GetSize(ctx context.Context) (string, error) {
-	npmCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "npm-cache")
-	exists, err := CheckPathExists(npmCache)
-	if err != nil {
-		return "Error", err
+// Available checks that the npm cache exists or npm is installed
+func (n *NPMCleaner) Available(ctx context.Context) error {
+	for _, path := range n.CachePaths() {
+		if exists, _ := CheckPathExists(path); exists {
+			return nil
+		}
 	}
-	if !exists {
-		return "Not found", nil
+	if _, err := exec.LookPath("npm"); err != nil {
+		return fmt.Errorf("npm cache not found and npm is not installed")
 	}
-	size, err := GetDirSize(npmCache)
-	if err != nil {
-		return "Error", err
+	return nil
+}
+
+// GetSize returns the size of npm cache
+func (n *NPMCleaner) GetSize(ctx context.Context) (string, error) {
+	return sizeOfPaths(n.CachePaths())
+}
+
+// Measure returns the size of npm cache in bytes
+func (n *NPMCleaner) Measure(ctx context.Context) (int64, error) {
+	return measurePaths(n.CachePaths())
+}
+
+// InUse reports whether npm is running or holds a lock in the cache
+func (n *NPMCleaner) InUse(ctx context.Context) error {
+	options := InUseOptions{Processes: []string{"npm", "npm-cli", "npx", "npx-cli"}}
+	for _, path := range n.CachePaths() {
+		options.LockFiles = append(options.LockFiles, filepath.Join(path, "_locks", "*"))
 	}
-	return FormatSize(size), nil
+	return options.Check(ctx, n.GetName())
 }
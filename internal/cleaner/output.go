@@ -0,0 +1,39 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+type outputKey struct{}
+
+// WithOutput returns ctx directing the progress messages of the cleaners
+// called with it, and the output of the commands they run, to w
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// Output returns the writer for progress messages carried by ctx, or
+// os.Stdout as it is when called
+func Output(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
+
+// printf writes a progress message to the output carried by ctx
+func printf(ctx context.Context, format string, args ...any) {
+	fmt.Fprintf(Output(ctx), format, args...)
+}
+
+// errorOutput returns the writer carried by ctx for the error output of the
+// commands cleaners run, or os.Stderr as it is when called
+func errorOutput(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stderr
+}
@@ -0,0 +1,198 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+)
+
+// PathOptions overrides where a path-based cleaner looks and what it removes
+type PathOptions struct {
+	// Paths replaces the default cache locations when set
+	Paths []string
+	// MinAge only removes entries not modified for at least this long
+	MinAge time.Duration
+}
+
+// IsSet reports whether any option differs from the cleaner's defaults
+func (o PathOptions) IsSet() bool {
+	return len(o.Paths) > 0 || o.MinAge > 0
+}
+
+// paths returns the configured paths, or the defaults if none are set
+func (o PathOptions) paths(defaults ...string) []string {
+	if len(o.Paths) > 0 {
+		return o.Paths
+	}
+	return defaults
+}
+
+// sizeOfPaths returns the combined size of the paths for GetSize
+func sizeOfPaths(paths []string) (string, error) {
+	var total int64
+	found := false
+	for _, path := range paths {
+		exists, err := CheckPathExists(path)
+		if err != nil {
+			return "Error", err
+		}
+		if !exists {
+			continue
+		}
+		size, err := GetDirSize(path)
+		if err != nil {
+			return "Error", err
+		}
+		total += size
+		found = true
+	}
+	if !found {
+		return "Not found", nil
+	}
+	return FormatSize(total), nil
+}
+
+// measurePaths returns the combined size of the paths in bytes
+func measurePaths(paths []string) (int64, error) {
+	var total int64
+	for _, path := range paths {
+		size, err := MeasurePath(path)
+		if err != nil {
+			return -1, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// newestModTime returns the most recent modification time of path or
+// anything below it
+func newestModTime(path string) time.Time {
+	var newest time.Time
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return newest
+}
+
+// dirItems lists the entries of dir as plan items. Entries modified within
+// minAge and entries named in skip are kept.
+func dirItems(dir string, minAge time.Duration, skip ...string) ([]PlanItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PlanItem
+	for _, entry := range entries {
+		items = append(items, pathItem(filepath.Join(dir, entry.Name()), minAge, skip...))
+	}
+	return items, nil
+}
+
+// pathItem returns the plan item for removing path
+func pathItem(path string, minAge time.Duration, skip ...string) PlanItem {
+	item := PlanItem{Target: path, Size: -1, Remove: true, Reason: "cache entry"}
+	for _, s := range skip {
+		if filepath.Base(path) == s {
+			item.Remove = false
+			item.Reason = "protected"
+			return item
+		}
+	}
+	if minAge > 0 {
+		if newestModTime(path).After(time.Now().Add(-minAge)) {
+			item.Remove = false
+			item.Reason = "modified within " + minAge.String()
+		} else {
+			item.Reason = "not modified for " + minAge.String()
+		}
+	}
+	return item
+}
+
+// removePath removes path, recording it and its size in the audit log
+func removePath(ctx context.Context, path string) error {
+	recorder := audit.FromContext(ctx)
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		// Nothing to remove, nothing to record
+		return nil
+	}
+	size := int64(-1)
+	if recorder != nil {
+		// Only measured for the audit log, as it means walking the tree
+		if measured, err := MeasurePath(path); err == nil {
+			size = measured
+		}
+	}
+	err := os.RemoveAll(path)
+	recorder.Removed(path, size, err)
+	return err
+}
+
+// cleanPaths removes the contents of each path, keeping entries modified
+// within minAge and entries named in skip. It logs progress with the
+// cleaner's name and succeeds if at least one entry could be removed or
+// there was nothing to remove.
+func cleanPaths(ctx context.Context, name string, paths []string, minAge time.Duration, skip ...string) error {
+	var items []PlanItem
+	for _, dir := range paths {
+		printf(ctx, "[%s] Cleaning %s...\n", name, dir)
+		found, err := dirItems(dir, minAge, skip...)
+		if os.IsNotExist(err) {
+			printf(ctx, "[%s] %s not found, skipping.\n", name, dir)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+		items = append(items, found...)
+	}
+	return removeItems(ctx, name, items)
+}
+
+// removeItems removes the items marked for removal, logging progress with
+// the cleaner's name. It succeeds if at least one item could be removed or
+// there was nothing to remove.
+func removeItems(ctx context.Context, name string, items []PlanItem) error {
+	var total, failed int
+	for _, item := range items {
+		if !item.Remove {
+			printf(ctx, "[%s] Skipping %s (%s)\n", name, item.Target, item.Reason)
+			audit.FromContext(ctx).Guard(item.Target, audit.Kept, item.Reason)
+			continue
+		}
+
+		total++
+		if err := removePath(ctx, item.Target); err != nil {
+			if os.IsPermission(err) {
+				printf(ctx, "[%s] Access denied for: %s\n", name, item.Target)
+			} else {
+				printf(ctx, "[%s] Failed to remove: %s (%v)\n", name, item.Target, err)
+			}
+			failed++
+		} else {
+			printf(ctx, "[%s] Successfully removed: %s\n", name, item.Target)
+		}
+	}
+
+	if failed == 0 {
+		printf(ctx, "[%s] Cleanup complete. %d items removed.\n", name, total)
+		return nil
+	}
+	if failed < total {
+		printf(ctx, "[%s] Partial cleanup complete. %d of %d items removed successfully.\n", name, total-failed, total)
+		return nil
+	}
+	return fmt.Errorf("failed to remove any of %d items", total)
+}
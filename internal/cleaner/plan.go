@@ -0,0 +1,38 @@
+package cleaner
+
+import "context"
+
+// PlanItem describes a single target a cleaner would remove or keep
+type PlanItem struct {
+	// Target identifies the item, e.g. a path, image tag or volume name
+	Target string
+	// Size is the estimated size in bytes, or -1 if unknown
+	Size int64
+	// Remove is true if the item would be removed, false if it is kept
+	Remove bool
+	// Reason explains why the item is kept or removed
+	Reason string
+}
+
+// Plan describes what a cleaner would do without doing it
+type Plan struct {
+	CleanerName string
+	Items       []PlanItem
+}
+
+// Reclaimable returns the total size of the items that would be removed
+func (p *Plan) Reclaimable() int64 {
+	var total int64
+	for _, item := range p.Items {
+		if item.Remove && item.Size > 0 {
+			total += item.Size
+		}
+	}
+	return total
+}
+
+// Planner is implemented by cleaners that can report what Clean would remove
+type Planner interface {
+	// Plan returns the items Clean would remove and the ones it would keep
+	Plan(ctx context.Context) (*Plan, error)
+}
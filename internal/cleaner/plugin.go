@@ -0,0 +1,153 @@
+package cleaner
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/plugin"
+)
+
+// PluginOptions configures how external cleaner plugins are run
+type PluginOptions struct {
+	// Timeout limits describe, size and plan calls
+	Timeout time.Duration
+	// CleanTimeout limits clean calls
+	CleanTimeout time.Duration
+}
+
+// DefaultPluginOptions returns the default plugin timeouts
+func DefaultPluginOptions() PluginOptions {
+	return PluginOptions{
+		Timeout:      30 * time.Second,
+		CleanTimeout: 10 * time.Minute,
+	}
+}
+
+// PluginEntry is a discovered plugin together with its description
+type PluginEntry struct {
+	plugin      *plugin.Plugin
+	description *plugin.Description
+}
+
+// Name returns the plugin's cleaner key, e.g. "foo" for clearance-cleaner-foo
+func (e PluginEntry) Name() string {
+	return e.plugin.Name
+}
+
+// Path returns the location of the plugin executable
+func (e PluginEntry) Path() string {
+	return e.plugin.Path
+}
+
+// Description returns how the plugin describes its cleaner, possibly empty
+func (e PluginEntry) Description() string {
+	return e.description.Description
+}
+
+// Icon returns the icon the plugin asked for, possibly empty
+func (e PluginEntry) Icon() string {
+	return e.description.Icon
+}
+
+// InAll reports whether the plugin asked to be selected by "all"
+func (e PluginEntry) InAll() bool {
+	return e.description.InAll
+}
+
+// DescribePlugins asks each plugin to describe itself. Plugins that fail are
+// left out and reported in the returned errors.
+func DescribePlugins(ctx context.Context, plugins []*plugin.Plugin, options PluginOptions) ([]PluginEntry, []error) {
+	var entries []PluginEntry
+	var errs []error
+	for _, p := range plugins {
+		d, err := p.Describe(ctx, options.Timeout)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, PluginEntry{plugin: p, description: d})
+	}
+	return entries, errs
+}
+
+// PluginCleaner runs an external cleaner plugin
+type PluginCleaner struct {
+	*BaseCleaner
+	plugin  *plugin.Plugin
+	options PluginOptions
+}
+
+// NewPluginCleaner creates a new PluginCleaner
+func NewPluginCleaner(p *plugin.Plugin, options PluginOptions) *PluginCleaner {
+	return &PluginCleaner{
+		BaseCleaner: NewBaseCleaner(p.Name),
+		plugin:      p,
+		options:     options,
+	}
+}
+
+// Clean asks the plugin to clean its cache
+func (c *PluginCleaner) Clean(ctx context.Context) error {
+	printf(ctx, "[%s] Running plugin %s...\n", c.GetName(), c.plugin.Path)
+	r, err := c.withOutput(ctx).Clean(ctx, c.options.CleanTimeout)
+	if err != nil {
+		return err
+	}
+	if r.Freed >= 0 {
+		printf(ctx, "[%s] Plugin reports %s freed.\n", c.GetName(), FormatSize(r.Freed))
+	}
+	return nil
+}
+
+// withOutput returns the plugin with its progress sent to the output carried
+// by ctx, unless the plugin already has a writer for it
+func (c *PluginCleaner) withOutput(ctx context.Context) *plugin.Plugin {
+	if c.plugin.Stderr != nil {
+		return c.plugin
+	}
+	p := *c.plugin
+	p.Stderr = Output(ctx)
+	return &p
+}
+
+// GetSize returns the size reported by the plugin
+func (c *PluginCleaner) GetSize(ctx context.Context) (string, error) {
+	r, err := c.withOutput(ctx).Size(ctx, c.options.Timeout)
+	if err != nil {
+		return "Error", err
+	}
+	if r.Status != "" {
+		return r.Status, nil
+	}
+	if r.Bytes < 0 {
+		return "N/A", nil
+	}
+	return FormatSize(r.Bytes), nil
+}
+
+// Measure returns the size reported by the plugin in bytes
+func (c *PluginCleaner) Measure(ctx context.Context) (int64, error) {
+	r, err := c.withOutput(ctx).Size(ctx, c.options.Timeout)
+	if err != nil {
+		return -1, err
+	}
+	return r.Bytes, nil
+}
+
+// Plan returns what the plugin would remove
+func (c *PluginCleaner) Plan(ctx context.Context) (*Plan, error) {
+	r, err := c.withOutput(ctx).Plan(ctx, c.options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{CleanerName: c.GetName()}
+	for _, item := range r.Items {
+		plan.Items = append(plan.Items, PlanItem{
+			Target: item.Target,
+			Size:   item.Size,
+			Remove: item.Remove,
+			Reason: item.Reason,
+		})
+	}
+	return plan, nil
+}
@@ -0,0 +1,48 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// NewPodmanCleaner creates a cleaner running the default modes against the
+// Podman API socket
+func NewPodmanCleaner(options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner("podman", "Podman", newPodmanEngine, DefaultDockerModes, options)
+}
+
+// NewPodmanModeCleaner creates a Podman cleaner running a single mode
+func NewPodmanModeCleaner(mode string, options DockerOptions) *ContainerCleaner {
+	return NewContainerCleaner("podman-"+mode, "Podman", newPodmanEngine, []string{mode}, options)
+}
+
+// newPodmanEngine connects to Podman's Docker-compatible API
+func newPodmanEngine() (ContainerEngine, error) {
+	return docker.NewClientWithHost(podmanHost())
+}
+
+// podmanHost returns CONTAINER_HOST or the default Podman socket. Rootless
+// users get their own socket under XDG_RUNTIME_DIR, which serves the
+// rootless storage in ~/.local/share/containers.
+func podmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	if runtime.GOOS == "windows" {
+		return "npipe:////./pipe/podman-machine-default"
+	}
+
+	uid := os.Geteuid()
+	if uid == 0 {
+		return "unix:///run/podman/podman.sock"
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", uid)
+	}
+	return "unix://" + filepath.Join(runtimeDir, "podman", "podman.sock")
+}
@@ -0,0 +1,71 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// Privileges describes what a cleaner needs beyond a normal user account
+type Privileges struct {
+	// Admin requires administrator privileges on Windows or root on Unix
+	Admin bool
+	// Groups are Unix groups whose members may run the cleaner without
+	// being root, e.g. "docker"
+	Groups []string
+}
+
+// IsZero reports whether no extra privileges are needed
+func (p Privileges) IsZero() bool {
+	return !p.Admin && len(p.Groups) == 0
+}
+
+// String describes the privileges, e.g. "root or membership in group docker"
+func (p Privileges) String() string {
+	admin := "root"
+	if runtime.GOOS == "windows" {
+		admin = "administrator privileges"
+	}
+	if len(p.Groups) == 0 {
+		return admin
+	}
+	return fmt.Sprintf("%s or membership in group %s", admin, strings.Join(p.Groups, " or "))
+}
+
+// Elevated is implemented by cleaners that may need more than user privileges
+type Elevated interface {
+	// RequiredPrivileges returns the privileges Clean needs on this machine
+	RequiredPrivileges(ctx context.Context) Privileges
+}
+
+// CheckPrivileges returns *errors.ErrAdminRequired if the process lacks the
+// given privileges
+func CheckPrivileges(p Privileges) error {
+	if p.IsZero() || isAdmin() {
+		return nil
+	}
+	for _, group := range p.Groups {
+		if inGroup(group) {
+			return nil
+		}
+	}
+	return errors.NewErrAdminRequired(p.String())
+}
+
+// CheckAdminPrivileges checks if the program is running with administrator
+// privileges, or as root on Unix
+func CheckAdminPrivileges() error {
+	return CheckPrivileges(Privileges{Admin: true})
+}
+
+// RequiredPrivileges returns the privileges c needs, none if it does not
+// implement Elevated
+func RequiredPrivileges(ctx context.Context, c Cleaner) Privileges {
+	if e, ok := c.(Elevated); ok {
+		return e.RequiredPrivileges(ctx)
+	}
+	return Privileges{}
+}
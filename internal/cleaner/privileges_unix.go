@@ -0,0 +1,62 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// isAdmin reports whether the process runs as root
+func isAdmin() bool {
+	return os.Geteuid() == 0
+}
+
+// inGroup reports whether the process is a member of the named group
+func inGroup(name string) bool {
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		return false
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return false
+	}
+	if os.Getegid() == gid {
+		return true
+	}
+	groups, err := os.Getgroups()
+	if err != nil {
+		return false
+	}
+	for _, g := range groups {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+// socketPrivileges returns the privileges needed to connect to the Unix
+// socket at path. A socket owned by root is only usable by root and, if it
+// is group-writable, by members of its group.
+func socketPrivileges(path string) Privileges {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Privileges{}
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Uid != 0 || info.Mode().Perm()&0o002 != 0 {
+		return Privileges{}
+	}
+
+	p := Privileges{Admin: true}
+	if info.Mode().Perm()&0o020 != 0 {
+		if group, err := user.LookupGroupId(strconv.Itoa(int(stat.Gid))); err == nil {
+			p.Groups = []string{group.Name}
+		}
+	}
+	return p
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package cleaner
+
+import "os"
+
+// isAdmin reports whether the process runs with administrator privileges
+func isAdmin() bool {
+	// Opening the physical drive requires administrator rights
+	f, err := os.Open("\\\\.\\PHYSICALDRIVE0")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// inGroup always reports false; Windows cleaners only declare Admin
+func inGroup(name string) bool {
+	return false
+}
+
+// socketPrivileges returns no requirements; named pipes are not owned by
+// Unix users
+func socketPrivileges(path string) Privileges {
+	return Privileges{}
+}
@@ -0,0 +1,32 @@
+//go:build !linux && !windows
+
+package cleaner
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processes lists the running processes with ps. Arguments are split on
+// spaces, so paths with spaces are not recognised.
+func processes(ctx context.Context) ([]process, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-axo", "pid=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []process
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		procs = append(procs, process{PID: pid, Names: processNames(fields[1:])})
+	}
+	return procs, nil
+}
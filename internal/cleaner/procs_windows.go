@@ -0,0 +1,28 @@
+package cleaner
+
+import (
+	"context"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processes lists the running processes from a Toolhelp snapshot. Only
+// executable names are available, so tools run by node are recognised by
+// their lock files rather than their scripts.
+func processes(ctx context.Context) ([]process, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	var procs []process
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		name := windows.UTF16ToString(entry.ExeFile[:])
+		procs = append(procs, process{PID: int(entry.ProcessID), Names: processNames([]string{name})})
+	}
+	return procs, nil
+}
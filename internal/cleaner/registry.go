@@ -0,0 +1,215 @@
+package cleaner
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Entry describes a cleaner that can be selected from the menu or command line
+type Entry struct {
+	// Key is the name used to select the cleaner, e.g. "npm"
+	Key string
+	// Description is a short human-readable description, e.g. "npm cache"
+	Description string
+	// Icon is shown next to the entry in the menu
+	Icon string
+	// InAll marks entries that are selected by "all"
+	InAll bool
+	// Part is the key of the entry this one is part of, e.g. "docker" for
+	// "docker-images"
+	Part string
+	// New creates the cleaner
+	New func() Cleaner
+}
+
+// Registry holds the selectable cleaners in menu order
+type Registry struct {
+	entries []Entry
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds an entry to the registry
+func (r *Registry) Register(entry Entry) {
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns all registered entries in menu order
+func (r *Registry) Entries() []Entry {
+	return r.entries
+}
+
+// reservedKeys are the menu words that select something other than a cleaner
+var reservedKeys = []string{"all", "report", "exit"}
+
+// ValidKey reports whether name can be used as a key: Lookup reads numbers
+// as menu positions, menus split selections at commas and spaces, and the
+// reserved words are handled before any cleaner is looked up
+func ValidKey(name string) bool {
+	if _, err := strconv.Atoi(name); err == nil {
+		return false
+	}
+	if slices.Contains(reservedKeys, name) {
+		return false
+	}
+	return name != "" && !strings.ContainsAny(name, ", \t") && name == strings.ToLower(name)
+}
+
+// Lookup finds an entry by key or by its 1-based menu number
+func (r *Registry) Lookup(selector string) (Entry, bool) {
+	selector = strings.ToLower(strings.TrimSpace(selector))
+	if n, err := strconv.Atoi(selector); err == nil {
+		if n >= 1 && n <= len(r.entries) {
+			return r.entries[n-1], true
+		}
+		return Entry{}, false
+	}
+	for _, e := range r.entries {
+		if e.Key == selector {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// All returns the entries selected by "all"
+func (r *Registry) All() []Entry {
+	var entries []Entry
+	for _, e := range r.entries {
+		if e.InAll {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// SetEnabled includes or excludes the entry with the given key from "all".
+// It returns false if there is no such entry.
+func (r *Registry) SetEnabled(key string, enabled bool) bool {
+	for i := range r.entries {
+		if r.entries[i].Key == key {
+			r.entries[i].InAll = enabled
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the keys of all registered entries in menu order
+func (r *Registry) Keys() []string {
+	keys := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+// RegistryOptions configures the built-in cleaners
+type RegistryOptions struct {
+	Docker DockerOptions
+	// ContainerdNamespaces are the containerd namespaces cleaned through nerdctl
+	ContainerdNamespaces []string
+	// Paths overrides the locations of path-based cleaners by key
+	Paths map[string]PathOptions
+	// Custom are the user-defined cleaners, listed after the built-in ones
+	Custom []CustomOptions
+	// Plugins are the external cleaner plugins, listed last
+	Plugins []PluginEntry
+	// PluginOptions configures how plugins are run
+	PluginOptions PluginOptions
+}
+
+// NewDefaultRegistry creates a registry with all built-in cleaners
+func NewDefaultRegistry(options RegistryOptions) *Registry {
+	dockerOptions := options.Docker
+
+	r := NewRegistry()
+	r.Register(Entry{Key: "npm", Description: "npm cache", Icon: "📦", InAll: true,
+		New: func() Cleaner { return NewNPMCleaner(options.Paths["npm"]) }})
+	r.Register(Entry{Key: "yarn", Description: "yarn cache", Icon: "🧶", InAll: true,
+		New: func() Cleaner { return NewYarnCleaner(options.Paths["yarn"]) }})
+	r.Register(Entry{Key: "docker", Description: "Docker cache (everything except volumes)", Icon: "🐳", InAll: true,
+		New: func() Cleaner { return NewDockerCleaner(dockerOptions) }})
+	r.Register(Entry{Key: "winsxs", Description: "WinSxS temp files", Icon: "🪟", InAll: true,
+		New: func() Cleaner { return NewWindowsCleaner("winsxs", options.Paths["winsxs"]) }})
+	r.Register(Entry{Key: "wintemp", Description: "Windows temporary files", Icon: "🗑️", InAll: true,
+		New: func() Cleaner { return NewWindowsCleaner("wintemp", options.Paths["wintemp"]) }})
+	r.Register(Entry{Key: "winchunks", Description: "Windows error reporting chunks", Icon: "📝", InAll: true,
+		New: func() Cleaner { return NewWindowsCleaner("winchunks", options.Paths["winchunks"]) }})
+
+	registerContainerModes(r, "docker", "Docker", "🐳", dockerOptions, func(key, mode string) Cleaner {
+		return NewDockerModeCleaner(mode, dockerOptions)
+	})
+
+	r.Register(Entry{Key: "podman", Description: "Podman cache (everything except volumes)", Icon: "🦭",
+		New: func() Cleaner { return NewPodmanCleaner(dockerOptions) }})
+	registerContainerModes(r, "podman", "Podman", "🦭", dockerOptions, func(key, mode string) Cleaner {
+		return NewPodmanModeCleaner(mode, dockerOptions)
+	})
+
+	for _, ns := range options.ContainerdNamespaces {
+		key, label := "nerdctl", "nerdctl"
+		if ns != "default" {
+			key, label = "nerdctl-"+ns, "nerdctl namespace "+ns
+		}
+		r.Register(Entry{Key: key, Description: label + " cache (everything except volumes)", Icon: "📦",
+			New: func() Cleaner { return NewNerdctlCleaner(key, ns, dockerOptions) }})
+		registerContainerModes(r, key, label, "📦", dockerOptions, func(key, mode string) Cleaner {
+			return NewNerdctlModeCleaner(key, ns, mode, dockerOptions)
+		})
+	}
+
+	for _, custom := range options.Custom {
+		description, icon := custom.Description, custom.Icon
+		if description == "" {
+			description = custom.Name
+		}
+		if icon == "" {
+			icon = "🧩"
+		}
+		r.Register(Entry{Key: custom.Name, Description: description, Icon: icon, InAll: custom.InAll,
+			New: func() Cleaner { return NewCustomCleaner(custom) }})
+	}
+
+	for _, entry := range options.Plugins {
+		description, icon := entry.Description(), entry.Icon()
+		if description == "" {
+			description = entry.Name() + " (plugin)"
+		}
+		if icon == "" {
+			icon = "🔌"
+		}
+		p := entry.plugin
+		r.Register(Entry{Key: p.Name, Description: description, Icon: icon, InAll: entry.InAll(),
+			New: func() Cleaner { return NewPluginCleaner(p, options.PluginOptions) }})
+	}
+
+	return r
+}
+
+// registerContainerModes registers one entry per Docker-style cleanup mode
+// under keys such as "docker-images"
+func registerContainerModes(r *Registry, prefix, label, icon string, options DockerOptions, newMode func(key, mode string) Cleaner) {
+	for _, mode := range DockerModes {
+		description := label + " " + DockerModeDescription(mode)
+		switch mode {
+		case DockerModeImages:
+			if options.ImageAge > 0 {
+				description += " older than " + strings.TrimSuffix(options.ImageAge.String(), "0m0s")
+			}
+		case DockerModeBuildCache:
+			if options.KeepStorage > 0 {
+				description += " (keeping " + FormatSize(options.KeepStorage) + ")"
+			}
+		case DockerModeVolumes:
+			description += " (opt-in, may contain data)"
+		}
+		key := prefix + "-" + mode
+		r.Register(Entry{Key: key, Description: description, Icon: icon, Part: prefix,
+			New: func() Cleaner { return newMode(key, mode) }})
+	}
+}
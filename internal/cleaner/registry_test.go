@@ -0,0 +1,27 @@
+package cleaner
+
+import "testing"
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"npm", true},
+		{"docker-images", true},
+		{"exits", true},
+		{"", false},
+		{"12", false},
+		{"Npm", false},
+		{"a b", false},
+		{"a,b", false},
+		{"all", false},
+		{"report", false},
+		{"exit", false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.name); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
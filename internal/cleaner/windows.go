@@ -7,26 +7,43 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/pkg/errors"
 )
 
 // WindowsCleaner handles cleaning of Windows system files
 type WindowsCleaner struct {
 	*BaseCleaner
 	cleanType string
+	options   PathOptions
 }
 
 // NewWindowsCleaner creates a new WindowsCleaner
-func NewWindowsCleaner(cleanType string) *WindowsCleaner {
+func NewWindowsCleaner(cleanType string, options PathOptions) *WindowsCleaner {
 	return &WindowsCleaner{
 		BaseCleaner: NewBaseCleaner(cleanType),
 		cleanType:   cleanType,
+		options:     options,
 	}
 }
 
 // Clean performs the Windows system cleaning operation
 func (w *WindowsCleaner) Clean(ctx context.Context) error {
 	if runtime.GOOS != "windows" {
-		return fmt.Errorf("%s cleanup is only available on Windows", w.cleanType)
+		return errors.NewErrNotSupported(w.cleanType+" cleanup", "only available on Windows")
+	}
+
+	if w.options.IsSet() {
+		path, err := w.path()
+		if err != nil {
+			return err
+		}
+		var skip []string
+		if w.cleanType == "winsxs" {
+			skip = []string{"InFlight", "PendingDeletes", "PendingRenames"}
+		}
+		return cleanPaths(ctx, w.cleanType, w.options.paths(path), w.options.MinAge, skip...)
 	}
 
 	switch w.cleanType {
@@ -41,44 +58,79 @@ func (w *WindowsCleaner) Clean(ctx context.Context) error {
 	}
 }
 
+// RequiredPrivileges returns administrator privileges for the WinSxS
+// cleaner, whose files live under the Windows directory
+func (w *WindowsCleaner) RequiredPrivileges(ctx context.Context) Privileges {
+	return Privileges{Admin: w.cleanType == "winsxs"}
+}
+
+// Available checks that this is Windows
+func (w *WindowsCleaner) Available(ctx context.Context) error {
+	if runtime.GOOS != "windows" {
+		return errors.NewErrNotSupported(w.cleanType+" cleanup", "only available on Windows")
+	}
+	return nil
+}
+
 // GetSize returns the size of Windows system files
 func (w *WindowsCleaner) GetSize(ctx context.Context) (string, error) {
 	if runtime.GOOS != "windows" {
 		return "N/A", nil
 	}
 
-	var path string
-	switch w.cleanType {
-	case "winsxs":
-		path = filepath.Join(os.Getenv("WINDIR"), "WinSxS", "Temp")
-	case "wintemp":
-		path = os.TempDir()
-	case "winchunks":
-		path = filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "WER", "ReportQueue")
-	default:
-		return "N/A", fmt.Errorf("unknown Windows cleaner type: %s", w.cleanType)
+	path, err := w.path()
+	if err != nil {
+		return "N/A", err
 	}
+	return sizeOfPaths(w.options.paths(path))
+}
 
-	exists, err := CheckPathExists(path)
+// Measure returns the size of Windows system files in bytes, or -1 if this
+// is not Windows
+func (w *WindowsCleaner) Measure(ctx context.Context) (int64, error) {
+	if runtime.GOOS != "windows" {
+		return -1, nil
+	}
+	path, err := w.path()
 	if err != nil {
-		return "Error", err
+		return -1, err
 	}
-	if !exists {
-		return "Not found", nil
+	return measurePaths(w.options.paths(path))
+}
+
+// CachePaths returns the directories the cleaner type operates on, or none
+// if this is not Windows
+func (w *WindowsCleaner) CachePaths() []string {
+	if runtime.GOOS != "windows" {
+		return nil
 	}
-	size, err := GetDirSize(path)
+	path, err := w.path()
 	if err != nil {
-		return "Error", err
+		return nil
+	}
+	return w.options.paths(path)
+}
+
+// path returns the directory the cleaner type operates on
+func (w *WindowsCleaner) path() (string, error) {
+	switch w.cleanType {
+	case "winsxs":
+		return filepath.Join(os.Getenv("WINDIR"), "WinSxS", "Temp"), nil
+	case "wintemp":
+		return os.TempDir(), nil
+	case "winchunks":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "WER", "ReportQueue"), nil
+	default:
+		return "", fmt.Errorf("unknown Windows cleaner type: %s", w.cleanType)
 	}
-	return FormatSize(size), nil
 }
 
 func (w *WindowsCleaner) cleanWinSxS(ctx context.Context) error {
-	fmt.Println("[winsxs] Attempting to clean WinSxS Temp folder...")
+	printf(ctx, "[winsxs] Attempting to clean WinSxS Temp folder...\n")
 	winsxsTemp := filepath.Join(os.Getenv("WINDIR"), "WinSxS", "Temp")
 
 	// First try: PowerShell command with elevated privileges
-	fmt.Println("[winsxs] Attempting to clean using PowerShell...")
+	printf(ctx, "[winsxs] Attempting to clean using PowerShell...\n")
 	psCmd := exec.CommandContext(ctx, "powershell", "-Command", `
 		$ErrorActionPreference = 'Stop'
 		$paths = @(
@@ -98,14 +150,16 @@ func (w *WindowsCleaner) cleanWinSxS(ctx context.Context) error {
 			}
 		}
 	`)
-	psCmd.Stdout = os.Stdout
-	psCmd.Stderr = os.Stderr
-	if err := psCmd.Run(); err != nil {
-		fmt.Printf("[winsxs] PowerShell cleanup encountered issues: %v\n", err)
+	psCmd.Stdout = Output(ctx)
+	psCmd.Stderr = errorOutput(ctx)
+	err := psCmd.Run()
+	audit.FromContext(ctx).Command(psCmd, err)
+	if err != nil {
+		printf(ctx, "[winsxs] PowerShell cleanup encountered issues: %v\n", err)
 	}
 
 	// Second try: Manual cleanup
-	fmt.Println("[winsxs] Attempting manual cleanup...")
+	printf(ctx, "[winsxs] Attempting manual cleanup...\n")
 	entries, err := os.ReadDir(winsxsTemp)
 	if err != nil {
 		return fmt.Errorf("failed to read WinSxS Temp folder: %w", err)
@@ -118,31 +172,32 @@ func (w *WindowsCleaner) cleanWinSxS(ctx context.Context) error {
 
 		// Skip system-protected folders
 		if entry.Name() == "InFlight" || entry.Name() == "PendingDeletes" || entry.Name() == "PendingRenames" {
-			fmt.Printf("[winsxs] Skipping system-protected folder: %s\n", entry.Name())
+			printf(ctx, "[winsxs] Skipping system-protected folder: %s\n", entry.Name())
+			audit.FromContext(ctx).Guard(path, audit.Kept, "system-protected folder")
 			continue
 		}
 
-		err := os.RemoveAll(path)
+		err := removePath(ctx, path)
 		if err != nil {
 			if os.IsPermission(err) {
-				fmt.Printf("[winsxs] Access denied for: %s (This is normal for system-protected files)\n", path)
+				printf(ctx, "[winsxs] Access denied for: %s (This is normal for system-protected files)\n", path)
 			} else {
-				fmt.Printf("[winsxs] Failed to remove: %s (%v)\n", path, err)
+				printf(ctx, "[winsxs] Failed to remove: %s (%v)\n", path, err)
 			}
 			failed++
 		} else {
-			fmt.Printf("[winsxs] Successfully removed: %s\n", path)
+			printf(ctx, "[winsxs] Successfully removed: %s\n", path)
 		}
 	}
 
 	if failed == 0 {
-		fmt.Println("[winsxs] WinSxS Temp folder cleaned successfully.")
+		printf(ctx, "[winsxs] WinSxS Temp folder cleaned successfully.\n")
 		return nil
 	}
 
 	if failed < total {
-		fmt.Printf("[winsxs] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
-		fmt.Println("[winsxs] Some files could not be deleted due to system protection. This is normal for active Windows Update operations.")
+		printf(ctx, "[winsxs] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
+		printf(ctx, "[winsxs] Some files could not be deleted due to system protection. This is normal for active Windows Update operations.\n")
 		return nil
 	}
 
@@ -150,7 +205,7 @@ func (w *WindowsCleaner) cleanWinSxS(ctx context.Context) error {
 }
 
 func (w *WindowsCleaner) cleanWindowsTemp(ctx context.Context) error {
-	fmt.Println("[wintemp] Attempting to clean Windows temporary files...")
+	printf(ctx, "[wintemp] Attempting to clean Windows temporary files...\n")
 	tempDir := os.TempDir()
 	entries, err := os.ReadDir(tempDir)
 	if err != nil {
@@ -165,31 +220,32 @@ func (w *WindowsCleaner) cleanWindowsTemp(ctx context.Context) error {
 		// Skip if the file is currently in use
 		if file, err := os.OpenFile(path, os.O_RDWR, 0); err == nil {
 			file.Close()
-			err := os.RemoveAll(path)
+			err := removePath(ctx, path)
 			if err != nil {
 				if os.IsPermission(err) {
-					fmt.Printf("[wintemp] Access denied for: %s (File might be in use)\n", path)
+					printf(ctx, "[wintemp] Access denied for: %s (File might be in use)\n", path)
 				} else {
-					fmt.Printf("[wintemp] Failed to remove: %s (%v)\n", path, err)
+					printf(ctx, "[wintemp] Failed to remove: %s (%v)\n", path, err)
 				}
 				failed++
 			} else {
-				fmt.Printf("[wintemp] Successfully removed: %s\n", path)
+				printf(ctx, "[wintemp] Successfully removed: %s\n", path)
 			}
 		} else {
-			fmt.Printf("[wintemp] Skipping in-use file: %s\n", path)
+			printf(ctx, "[wintemp] Skipping in-use file: %s\n", path)
+			audit.FromContext(ctx).Guard(path, audit.Kept, "in use")
 			failed++
 		}
 	}
 
 	if failed == 0 {
-		fmt.Println("[wintemp] Windows temp folder cleaned successfully.")
+		printf(ctx, "[wintemp] Windows temp folder cleaned successfully.\n")
 		return nil
 	}
 
 	if failed < total {
-		fmt.Printf("[wintemp] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
-		fmt.Println("[wintemp] Some files could not be deleted as they are currently in use.")
+		printf(ctx, "[wintemp] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
+		printf(ctx, "[wintemp] Some files could not be deleted as they are currently in use.\n")
 		return nil
 	}
 
@@ -197,15 +253,15 @@ func (w *WindowsCleaner) cleanWindowsTemp(ctx context.Context) error {
 }
 
 func (w *WindowsCleaner) cleanWindowsChunks(ctx context.Context) error {
-	fmt.Println("[winchunks] Attempting to clean Windows error reporting chunks...")
+	printf(ctx, "[winchunks] Attempting to clean Windows error reporting chunks...\n")
 	chunkDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "WER", "ReportQueue")
 	if _, err := os.Stat(chunkDir); os.IsNotExist(err) {
-		fmt.Println("[winchunks] ReportQueue directory not found.")
+		printf(ctx, "[winchunks] ReportQueue directory not found.\n")
 		return nil
 	}
 
 	// First try: PowerShell command with elevated privileges
-	fmt.Println("[winchunks] Attempting to clean using PowerShell...")
+	printf(ctx, "[winchunks] Attempting to clean using PowerShell...\n")
 	psCmd := exec.CommandContext(ctx, "powershell", "-Command", `
 		$ErrorActionPreference = 'Stop'
 		$chunkDir = Join-Path $env:LOCALAPPDATA "Microsoft\Windows\WER\ReportQueue"
@@ -218,14 +274,16 @@ func (w *WindowsCleaner) cleanWindowsChunks(ctx context.Context) error {
 			}
 		}
 	`)
-	psCmd.Stdout = os.Stdout
-	psCmd.Stderr = os.Stderr
-	if err := psCmd.Run(); err != nil {
-		fmt.Printf("[winchunks] PowerShell cleanup encountered issues: %v\n", err)
+	psCmd.Stdout = Output(ctx)
+	psCmd.Stderr = errorOutput(ctx)
+	err := psCmd.Run()
+	audit.FromContext(ctx).Command(psCmd, err)
+	if err != nil {
+		printf(ctx, "[winchunks] PowerShell cleanup encountered issues: %v\n", err)
 	}
 
 	// Second try: Manual cleanup
-	fmt.Println("[winchunks] Attempting manual cleanup...")
+	printf(ctx, "[winchunks] Attempting manual cleanup...\n")
 	entries, err := os.ReadDir(chunkDir)
 	if err != nil {
 		return fmt.Errorf("failed to read ReportQueue directory: %w", err)
@@ -236,27 +294,27 @@ func (w *WindowsCleaner) cleanWindowsChunks(ctx context.Context) error {
 		total++
 		path := filepath.Join(chunkDir, entry.Name())
 
-		err := os.RemoveAll(path)
+		err := removePath(ctx, path)
 		if err != nil {
 			if os.IsPermission(err) {
-				fmt.Printf("[winchunks] Access denied for: %s (This is normal for system-protected files)\n", path)
+				printf(ctx, "[winchunks] Access denied for: %s (This is normal for system-protected files)\n", path)
 			} else {
-				fmt.Printf("[winchunks] Failed to remove: %s (%v)\n", path, err)
+				printf(ctx, "[winchunks] Failed to remove: %s (%v)\n", path, err)
 			}
 			failed++
 		} else {
-			fmt.Printf("[winchunks] Successfully removed: %s\n", path)
+			printf(ctx, "[winchunks] Successfully removed: %s\n", path)
 		}
 	}
 
 	if failed == 0 {
-		fmt.Println("[winchunks] Windows chunks cleaned successfully.")
+		printf(ctx, "[winchunks] Windows chunks cleaned successfully.\n")
 		return nil
 	}
 
 	if failed < total {
-		fmt.Printf("[winchunks] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
-		fmt.Println("[winchunks] Some files could not be deleted due to system protection.")
+		printf(ctx, "[winchunks] Partial cleanup complete. %d of %d items removed successfully.\n", total-failed, total)
+		printf(ctx, "[winchunks] Some files could not be deleted due to system protection.\n")
 		return nil
 	}
 
@@ -6,62 +6,94 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
 )
 
 // YarnCleaner handles cleaning of yarn cache
 type YarnCleaner struct {
 	*BaseCleaner
+	options PathOptions
 }
 
 // NewYarnCleaner creates a new YarnCleaner
-func NewYarnCleaner() *YarnCleaner {
+func NewYarnCleaner(options PathOptions) *YarnCleaner {
 	return &YarnCleaner{
 		BaseCleaner: NewBaseCleaner("yarn"),
+		options:     options,
 	}
 }
 
+// CachePaths returns the yarn cache locations
+func (y *YarnCleaner) CachePaths() []string {
+	return y.options.paths(filepath.Join(os.Getenv("LOCALAPPDATA"), "Yarn", "Cache"))
+}
+
 // Clean performs the yarn cache cleaning operation
 func (y *YarnCleaner) Clean(ctx context.Context) error {
-	fmt.Println("[yarn] Attempting to remove yarn cache folder...")
+	if y.options.IsSet() {
+		return cleanPaths(ctx, y.GetName(), y.CachePaths(), y.options.MinAge)
+	}
+
+	printf(ctx, "[yarn] Attempting to remove yarn cache folder...\n")
 	yarnCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "Yarn", "cache", "v6")
-	if err := os.RemoveAll(yarnCache); err == nil {
-		fmt.Println("[yarn] Folder removed successfully.")
+	if err := removePath(ctx, yarnCache); err == nil {
+		printf(ctx, "[yarn] Folder removed successfully.\n")
 		return nil
 	} else {
-		fmt.Printf("[yarn] Folder removal failed: %v\n", err)
+		printf(ctx, "[yarn] Folder removal failed: %v\n", err)
 	}
 
-	fmt.Println("[yarn] Fallback: running 'yarn cache clean'...")
+	printf(ctx, "[yarn] Fallback: running 'yarn cache clean'...\n")
 	if yarnPath, err := exec.LookPath("yarn"); err == nil {
 		cmd := exec.CommandContext(ctx, yarnPath, "cache", "clean")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err == nil {
-			fmt.Println("[yarn] yarn CLI cache clean succeeded.")
+		cmd.Stdout = Output(ctx)
+		cmd.Stderr = errorOutput(ctx)
+		err := cmd.Run()
+		audit.FromContext(ctx).Command(cmd, err)
+		if err == nil {
+			printf(ctx, "[yarn] yarn CLI cache clean succeeded.\n")
 			return nil
 		} else {
-			fmt.Printf("[yarn] yarn CLI cache clean failed: %v\n", err)
+			printf(ctx, "[yarn] yarn CLI cache clean failed: %v\n", err)
 		}
 	} else {
-		fmt.Println("[yarn] yarn not found in PATH.")
+		printf(ctx, "[yarn] yarn not found in PATH.\n")
 	}
 
 	return fmt.Errorf("failed to clean yarn cache using both direct deletion and yarn CLI")
 }
 
-// GetSize returns the size of yarn cache
-func (y *YarnCleaner) GetSize(ctx context.Context) (string, error) {
-	yarnCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "Yarn", "Cache")
-	exists, err := CheckPathExists(yarnCache)
-	if err != nil {
-		return "Error", err
+// Available checks that the yarn cache exists or yarn is installed
+func (y *YarnCleaner) Available(ctx context.Context) error {
+	for _, path := range y.CachePaths() {
+		if exists, _ := CheckPathExists(path); exists {
+			return nil
+		}
 	}
-	if !exists {
-		return "Not found", nil
+	if _, err := exec.LookPath("yarn"); err != nil {
+		return fmt.Errorf("yarn cache not found and yarn is not installed")
 	}
-	size, err := GetDirSize(yarnCache)
-	if err != nil {
-		return "Error", err
+	return nil
+}
+
+// GetSize returns the size of yarn cache
+func (y *YarnCleaner) GetSize(ctx context.Context) (string, error) {
+	return sizeOfPaths(y.CachePaths())
+}
+
+// Measure returns the size of yarn cache in bytes
+func (y *YarnCleaner) Measure(ctx context.Context) (int64, error) {
+	return measurePaths(y.CachePaths())
+}
+
+// InUse reports whether yarn is running or holds a lock in the cache
+func (y *YarnCleaner) InUse(ctx context.Context) error {
+	options := InUseOptions{Processes: []string{"yarn", "yarnpkg", "yarn-*"}}
+	for _, path := range y.CachePaths() {
+		options.LockFiles = append(options.LockFiles,
+			filepath.Join(path, ".yarn-lock"),
+			filepath.Join(path, "*", ".yarn-lock"))
 	}
-	return FormatSize(size), nil
+	return options.Check(ctx, y.GetName())
 }
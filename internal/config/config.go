@@ -1,10 +1,22 @@
 package config
 
+import "gopkg.in/yaml.v3"
+
 // Config holds the application configuration
 type Config struct {
 	Version string
 	Commit  string
 	Date    string
+
+	// Settings are the effective settings after loading the configuration
+	// files and applying the profile
+	Settings Settings
+	// Sources lists the configuration files that were loaded
+	Sources []string
+	// Profile is the applied profile, empty if none
+	Profile string
+
+	profiles map[string]yaml.Node
 }
 
 // NewConfig creates a new Config instance
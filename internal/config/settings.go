@@ -0,0 +1,436 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/cleaner"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the user configuration file
+const FileName = "config.yaml"
+
+// LocalFileName is the name of the repo-local override file
+const LocalFileName = ".clearance.yaml"
+
+// CleanerSettings configures a single cleaner
+type CleanerSettings struct {
+	// Enabled includes or excludes the cleaner from "all" and profile runs
+	Enabled *bool `yaml:"enabled"`
+	// Paths replaces the cleaner's default cache locations
+	Paths []string `yaml:"paths"`
+	// MinAge only removes entries not modified for at least this long
+	MinAge *time.Duration `yaml:"min_age"`
+}
+
+// merge applies the fields set in o on top of cs
+func (cs CleanerSettings) merge(o CleanerSettings) CleanerSettings {
+	if o.Enabled != nil {
+		cs.Enabled = o.Enabled
+	}
+	if o.Paths != nil {
+		cs.Paths = o.Paths
+	}
+	if o.MinAge != nil {
+		cs.MinAge = o.MinAge
+	}
+	return cs
+}
+
+// CustomSettings declares a user-defined cleaner
+type CustomSettings struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Icon        string `yaml:"icon"`
+	// Paths are paths or glob patterns; ~ and environment variables are expanded
+	Paths []string `yaml:"paths"`
+	// Root is the directory every matched path must resolve inside of
+	Root   string        `yaml:"root"`
+	MinAge time.Duration `yaml:"min_age"`
+	Pre    string        `yaml:"pre"`
+	Post   string        `yaml:"post"`
+	// Enabled includes the cleaner in "all", true by default
+	Enabled *bool `yaml:"enabled"`
+	// InUse leaves the cache alone while the tool using it is running
+	InUse InUseSettings `yaml:"in_use"`
+}
+
+// InUseSettings tells when a custom cleaner's cache is in use
+type InUseSettings struct {
+	// Processes are executable names or glob patterns, e.g. "gradle"
+	Processes []string `yaml:"processes"`
+	// LockFiles are glob patterns of lock files held while the cache is used
+	LockFiles []string `yaml:"lock_files"`
+}
+
+// Options converts the settings to cleaner options
+func (c CustomSettings) Options() cleaner.CustomOptions {
+	return cleaner.CustomOptions{
+		Name:        c.Name,
+		Description: c.Description,
+		Icon:        c.Icon,
+		Paths:       c.Paths,
+		Root:        c.Root,
+		MinAge:      c.MinAge,
+		Pre:         c.Pre,
+		Post:        c.Post,
+		InAll:       c.Enabled == nil || *c.Enabled,
+		InUse: cleaner.InUseOptions{
+			Processes: c.InUse.Processes,
+			LockFiles: c.InUse.LockFiles,
+		},
+	}
+}
+
+// DockerSettings configures the Docker, Podman and nerdctl cleaners
+type DockerSettings struct {
+	ImageAge    *time.Duration `yaml:"image_age"`
+	KeepStorage string         `yaml:"keep_storage"`
+	KeepLabels  []string       `yaml:"keep_labels"`
+	KeepNames   []string       `yaml:"keep_names"`
+	KeepRepos   []string       `yaml:"keep_repos"`
+	KeepRecent  int            `yaml:"keep_recent"`
+	Namespaces  []string       `yaml:"containerd_namespaces"`
+}
+
+// PluginSettings configures external cleaner plugins
+type PluginSettings struct {
+	// Disabled turns off plugin discovery
+	Disabled bool `yaml:"disabled"`
+	// Timeout limits describe, size and plan calls
+	Timeout time.Duration `yaml:"timeout"`
+	// CleanTimeout limits clean calls
+	CleanTimeout time.Duration `yaml:"clean_timeout"`
+}
+
+// OutputSettings holds output defaults
+type OutputSettings struct {
+	DryRun bool `yaml:"dry_run"`
+}
+
+// WatchSettings configures clearance watch
+type WatchSettings struct {
+	// MinFree is the free space to keep, e.g. "15GB"
+	MinFree string `yaml:"min_free"`
+	// Path is on the file system to watch, the home directory by default
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+	// Cooldown is how long a cleaner is left alone after it ran
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Cleaners are run in order, least costly first, until enough space is free
+	Cleaners []string `yaml:"cleaners"`
+}
+
+// AuditSettings configures the audit log
+type AuditSettings struct {
+	// Disabled turns off the audit log
+	Disabled bool `yaml:"disabled"`
+	// Path replaces the default location in the config directory; ~ and
+	// environment variables are expanded
+	Path string `yaml:"path"`
+}
+
+// Settings are the user-configurable options. Profiles use the same schema
+// and are applied on top of the top-level settings.
+type Settings struct {
+	Cleaners map[string]CleanerSettings `yaml:"cleaners"`
+	Custom   []CustomSettings           `yaml:"custom"`
+	Docker   DockerSettings             `yaml:"docker"`
+	Plugins  PluginSettings             `yaml:"plugins"`
+	Output   OutputSettings             `yaml:"output"`
+	Watch    WatchSettings              `yaml:"watch"`
+	Audit    AuditSettings              `yaml:"audit"`
+}
+
+// file is the on-disk configuration format
+type file struct {
+	Settings `yaml:",inline"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+// UserFile returns the path of the user configuration file,
+// $XDG_CONFIG_HOME/clearance/config.yaml on Linux
+func UserFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "clearance", FileName), nil
+}
+
+// LocalFile looks for a repo-local override in dir and its parents, stopping
+// at the repository root. It returns "" if there is none. A local file may
+// not set custom cleaners, cleaner paths, plugins or the audit log.
+func LocalFile(dir string) string {
+	for {
+		path := filepath.Join(dir, LocalFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the user configuration file and the repo-local override, if
+// present, and applies the named profile. An empty path uses UserFile.
+func (c *Config) Load(path, profile string) error {
+	if path == "" {
+		userFile, err := UserFile()
+		if err != nil {
+			return err
+		}
+		path = userFile
+	}
+
+	paths := []string{path}
+	if wd, err := os.Getwd(); err == nil {
+		if local := LocalFile(wd); local != "" && local != path {
+			paths = append(paths, local)
+		}
+	}
+
+	var f file
+	for i, p := range paths {
+		data, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if i > 0 {
+			if err := checkLocal(data); err != nil {
+				return fmt.Errorf("invalid configuration in %s: %w", p, err)
+			}
+		}
+		base := f.Cleaners
+		f.Cleaners = nil
+		if err := decode(data, &f); err != nil {
+			return fmt.Errorf("invalid configuration in %s: %w", p, err)
+		}
+		f.Cleaners = mergeCleaners(base, f.Cleaners)
+		c.Sources = append(c.Sources, p)
+	}
+
+	c.Settings = f.Settings
+	c.profiles = f.Profiles
+	if profile == "" {
+		return nil
+	}
+
+	node, ok := f.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (available: %v)", profile, c.ProfileNames())
+	}
+	base := c.Settings.Cleaners
+	c.Settings.Cleaners = nil
+	if err := node.Decode(&c.Settings); err != nil {
+		return fmt.Errorf("invalid profile %q: %w", profile, err)
+	}
+	c.Settings.Cleaners = mergeCleaners(base, c.Settings.Cleaners)
+	c.Profile = profile
+	return nil
+}
+
+// mergeCleaners applies the cleaner settings of a later layer on top of
+// base field by field, so setting one field of a cleaner keeps the others
+func mergeCleaners(base, layer map[string]CleanerSettings) map[string]CleanerSettings {
+	if len(layer) == 0 {
+		return base
+	}
+	merged := make(map[string]CleanerSettings, len(base)+len(layer))
+	maps.Copy(merged, base)
+	for name, cs := range layer {
+		merged[name] = merged[name].merge(cs)
+	}
+	return merged
+}
+
+// decode strictly decodes YAML on top of the existing values in f. Structs
+// are overridden key by key, but map entries are replaced whole, so callers
+// merge the cleaner settings with mergeCleaners.
+func decode(data []byte, f *file) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// checkLocal rejects the settings a repo-local file may not set. Custom
+// cleaners run commands, cleaner paths and plugins choose what is deleted,
+// and the audit log must not be silenced, so anyone checking out a
+// repository could otherwise abuse them.
+func checkLocal(data []byte) error {
+	var f file
+	if err := decode(data, &f); err != nil {
+		return err
+	}
+	keys := f.Settings.restricted("")
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var pf file
+		node := f.Profiles[name]
+		if err := node.Decode(&pf); err != nil {
+			return fmt.Errorf("invalid profile %q: %w", name, err)
+		}
+		keys = append(keys, pf.Settings.restricted("profiles."+name+".")...)
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("%s may only be set in the user configuration file or --config", strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// restricted returns the keys set in s that a repo-local file may not set
+func (s *Settings) restricted(prefix string) []string {
+	var keys []string
+	if len(s.Custom) > 0 {
+		keys = append(keys, prefix+"custom")
+	}
+	names := make([]string, 0, len(s.Cleaners))
+	for name, cs := range s.Cleaners {
+		if cs.Paths != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		keys = append(keys, prefix+"cleaners."+name+".paths")
+	}
+	if s.Plugins != (PluginSettings{}) {
+		keys = append(keys, prefix+"plugins")
+	}
+	if s.Audit != (AuditSettings{}) {
+		keys = append(keys, prefix+"audit")
+	}
+	return keys
+}
+
+// ProfileNames returns the names of the configured profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.profiles))
+	for name := range c.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the settings and every profile against the built-in
+// cleaner names, returning all problems found
+func (c *Config) Validate(knownCleaners []string) []error {
+	builtin := make(map[string]bool)
+	for _, name := range knownCleaners {
+		builtin[name] = true
+	}
+
+	errs := c.Settings.validate("", builtin, nil)
+	// Profiles may refer to the top-level custom cleaners
+	custom := make(map[string]bool)
+	for _, cs := range c.Settings.Custom {
+		custom[cs.Name] = true
+	}
+	for _, name := range c.ProfileNames() {
+		// Decode each profile on its own so strict field checks apply to it
+		var pf file
+		node := c.profiles[name]
+		data, err := yaml.Marshal(&node)
+		if err == nil {
+			err = decode(data, &pf)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", name, err))
+			continue
+		}
+		errs = append(errs, pf.Settings.validate("profile "+name+": ", builtin, custom)...)
+	}
+	return errs
+}
+
+// validate checks values that YAML decoding cannot. Cleaner settings may
+// refer to builtin cleaners, the custom cleaners in s, or those in inherited.
+func (s *Settings) validate(prefix string, builtin, inherited map[string]bool) []error {
+	var errs []error
+	custom := make(map[string]bool)
+	for name := range inherited {
+		custom[name] = true
+	}
+	declared := make(map[string]bool)
+	for _, cs := range s.Custom {
+		if err := cs.Options().Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s%w", prefix, err))
+		}
+		if builtin[cs.Name] {
+			errs = append(errs, fmt.Errorf("%scustom cleaner %q clashes with a built-in cleaner", prefix, cs.Name))
+		}
+		if declared[cs.Name] {
+			errs = append(errs, fmt.Errorf("%scustom cleaner %q is declared more than once", prefix, cs.Name))
+		}
+		declared[cs.Name] = true
+		custom[cs.Name] = true
+	}
+
+	for name, cs := range s.Cleaners {
+		if !builtin[name] && !custom[name] {
+			errs = append(errs, fmt.Errorf("%sunknown cleaner %q", prefix, name))
+		}
+		if cs.MinAge != nil && *cs.MinAge < 0 {
+			errs = append(errs, fmt.Errorf("%scleaner %s: min_age must not be negative", prefix, name))
+		}
+	}
+	if s.Docker.ImageAge != nil && *s.Docker.ImageAge < 0 {
+		errs = append(errs, fmt.Errorf("%sdocker.image_age must not be negative", prefix))
+	}
+	if s.Docker.KeepStorage != "" {
+		if _, err := cleaner.ParseSize(s.Docker.KeepStorage); err != nil {
+			errs = append(errs, fmt.Errorf("%sdocker.keep_storage: %w", prefix, err))
+		}
+	}
+	if s.Plugins.Timeout < 0 || s.Plugins.CleanTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%splugins: timeouts must not be negative", prefix))
+	}
+	if s.Docker.KeepRecent < 0 {
+		errs = append(errs, fmt.Errorf("%sdocker.keep_recent must not be negative", prefix))
+	}
+	if s.Watch.MinFree != "" {
+		if _, err := cleaner.ParseSize(s.Watch.MinFree); err != nil {
+			errs = append(errs, fmt.Errorf("%swatch.min_free: %w", prefix, err))
+		}
+	}
+	if s.Watch.Interval < 0 || s.Watch.Cooldown < 0 {
+		errs = append(errs, fmt.Errorf("%swatch: interval and cooldown must not be negative", prefix))
+	}
+	for _, name := range s.Watch.Cleaners {
+		if !builtin[name] && !custom[name] {
+			errs = append(errs, fmt.Errorf("%swatch.cleaners: unknown cleaner %q", prefix, name))
+		}
+	}
+	for _, pattern := range s.Docker.KeepNames {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("%sdocker.keep_names: invalid pattern %q", prefix, pattern))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const userConfig = `
+cleaners:
+  npm:
+    paths: [/data/npm]
+    min_age: 24h
+  yarn:
+    enabled: false
+profiles:
+  ci:
+    cleaners:
+      npm:
+        enabled: false
+`
+
+// load writes the user configuration and, if local is not empty, a
+// repo-local file in a repository containing the working directory, then
+// loads them with the given profile
+func load(t *testing.T, user, local, profile string) (*Config, error) {
+	t.Helper()
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(userFile, []byte(user), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(dir, "repo")
+	work := filepath.Join(repo, "sub")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(work, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if local != "" {
+		if err := os.WriteFile(filepath.Join(repo, LocalFileName), []byte(local), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	c := NewConfig("", "", "")
+	return c, c.Load(userFile, profile)
+}
+
+func duration(d time.Duration) *time.Duration { return &d }
+
+func boolean(b bool) *bool { return &b }
+
+func TestLoadMergesCleanerSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		local   string
+		profile string
+		want    map[string]CleanerSettings
+	}{
+		{
+			name: "user only",
+			want: map[string]CleanerSettings{
+				"npm":  {Paths: []string{"/data/npm"}, MinAge: duration(24 * time.Hour)},
+				"yarn": {Enabled: boolean(false)},
+			},
+		},
+		{
+			name:    "profile over user",
+			profile: "ci",
+			want: map[string]CleanerSettings{
+				"npm":  {Enabled: boolean(false), Paths: []string{"/data/npm"}, MinAge: duration(24 * time.Hour)},
+				"yarn": {Enabled: boolean(false)},
+			},
+		},
+		{
+			name:  "local over user",
+			local: "cleaners:\n  npm:\n    min_age: 1h\n  yarn:\n    enabled: true\n",
+			want: map[string]CleanerSettings{
+				"npm":  {Paths: []string{"/data/npm"}, MinAge: duration(time.Hour)},
+				"yarn": {Enabled: boolean(true)},
+			},
+		},
+		{
+			name:    "profile over local over user",
+			local:   "cleaners:\n  npm:\n    min_age: 1h\n",
+			profile: "ci",
+			want: map[string]CleanerSettings{
+				"npm":  {Enabled: boolean(false), Paths: []string{"/data/npm"}, MinAge: duration(time.Hour)},
+				"yarn": {Enabled: boolean(false)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := load(t, userConfig, tt.local, tt.profile)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(c.Settings.Cleaners, tt.want) {
+				t.Errorf("cleaners = %s, want %s", format(c.Settings.Cleaners), format(tt.want))
+			}
+		})
+	}
+}
+
+func TestLoadRejectsRestrictedLocalSettings(t *testing.T) {
+	tests := []struct {
+		local string
+		key   string
+	}{
+		{"cleaners:\n  npm:\n    paths: [/]\n", "cleaners.npm.paths"},
+		{"cleaners:\n  npm:\n    paths: []\n", "cleaners.npm.paths"},
+		{"custom:\n  - name: x\n    paths: [/tmp/x/*]\n    root: /tmp/x\n", "custom"},
+		{"plugins:\n  disabled: true\n", "plugins"},
+		{"audit:\n  disabled: true\n", "audit"},
+		{"profiles:\n  ci:\n    audit:\n      path: /tmp/a\n", "profiles.ci.audit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, err := load(t, userConfig, tt.local, "")
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("Load error = %v, want one naming %s", err, tt.key)
+			}
+		})
+	}
+}
+
+// format prints cleaner settings with the values behind their pointers
+func format(cleaners map[string]CleanerSettings) string {
+	var b strings.Builder
+	for name, cs := range cleaners {
+		b.WriteString(name + ":{")
+		if cs.Enabled != nil {
+			b.WriteString("enabled=" + strconv.FormatBool(*cs.Enabled) + " ")
+		}
+		b.WriteString("paths=" + strings.Join(cs.Paths, ","))
+		if cs.MinAge != nil {
+			b.WriteString(" min_age=" + cs.MinAge.String())
+		}
+		b.WriteString("} ")
+	}
+	return b.String()
+}
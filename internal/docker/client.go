@@ -0,0 +1,225 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUnixHost    = "unix:///var/run/docker.sock"
+	defaultWindowsHost = "npipe:////./pipe/docker_engine"
+)
+
+// Client talks to the Docker Engine HTTP API
+type Client struct {
+	host       string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for DOCKER_HOST, falling back to the platform default socket
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = DefaultHost()
+	}
+	return NewClientWithHost(host)
+}
+
+// DefaultHost returns the default Docker daemon address for the current platform
+func DefaultHost() string {
+	if runtime.GOOS == "windows" {
+		return defaultWindowsHost
+	}
+	return defaultUnixHost
+}
+
+// NewClientWithHost creates a Client for the given daemon address.
+// Supported schemes are unix://, npipe://, tcp:// and http://.
+func NewClientWithHost(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	baseURL := "http://docker"
+
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+	case "npipe":
+		path := strings.ReplaceAll(u.Path, "/", `\`)
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialPipe(path)
+		}
+	case "tcp", "http":
+		baseURL = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme: %s", u.Scheme)
+	}
+
+	return &Client{
+		host:    host,
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Transport: transport,
+		},
+	}, nil
+}
+
+// Host returns the daemon address the client is connected to
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Ping checks whether the Docker daemon is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil)
+}
+
+// DiskUsage returns the daemon's disk usage broken down by category
+func (c *Client) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	var du DiskUsage
+	if err := c.do(ctx, http.MethodGet, "/system/df", nil, &du); err != nil {
+		return nil, err
+	}
+	return &du, nil
+}
+
+// PruneContainers removes stopped containers
+func (c *Client) PruneContainers(ctx context.Context, filters Filters) (*PruneReport, error) {
+	var resp struct {
+		ContainersDeleted []string
+		SpaceReclaimed    int64
+	}
+	if err := c.do(ctx, http.MethodPost, "/containers/prune", filters.query(), &resp); err != nil {
+		return nil, err
+	}
+	return &PruneReport{Deleted: resp.ContainersDeleted, SpaceReclaimed: resp.SpaceReclaimed}, nil
+}
+
+// PruneImages removes unused images
+func (c *Client) PruneImages(ctx context.Context, filters Filters) (*PruneReport, error) {
+	var resp struct {
+		ImagesDeleted []struct {
+			Untagged string
+			Deleted  string
+		}
+		SpaceReclaimed int64
+	}
+	if err := c.do(ctx, http.MethodPost, "/images/prune", filters.query(), &resp); err != nil {
+		return nil, err
+	}
+	report := &PruneReport{SpaceReclaimed: resp.SpaceReclaimed}
+	for _, img := range resp.ImagesDeleted {
+		if img.Deleted != "" {
+			report.Deleted = append(report.Deleted, img.Deleted)
+		}
+	}
+	return report, nil
+}
+
+// PruneVolumes removes unused volumes
+func (c *Client) PruneVolumes(ctx context.Context, filters Filters) (*PruneReport, error) {
+	var resp struct {
+		VolumesDeleted []string
+		SpaceReclaimed int64
+	}
+	if err := c.do(ctx, http.MethodPost, "/volumes/prune", filters.query(), &resp); err != nil {
+		return nil, err
+	}
+	return &PruneReport{Deleted: resp.VolumesDeleted, SpaceReclaimed: resp.SpaceReclaimed}, nil
+}
+
+// PruneNetworks removes unused networks
+func (c *Client) PruneNetworks(ctx context.Context, filters Filters) (*PruneReport, error) {
+	var resp struct {
+		NetworksDeleted []string
+	}
+	if err := c.do(ctx, http.MethodPost, "/networks/prune", filters.query(), &resp); err != nil {
+		return nil, err
+	}
+	return &PruneReport{Deleted: resp.NetworksDeleted}, nil
+}
+
+// PruneBuildCache removes build cache records, keeping up to keepStorage bytes when it is positive
+func (c *Client) PruneBuildCache(ctx context.Context, all bool, keepStorage int64, filters Filters) (*PruneReport, error) {
+	query := filters.query()
+	if all {
+		query.Set("all", "true")
+	}
+	if keepStorage > 0 {
+		query.Set("keep-storage", strconv.FormatInt(keepStorage, 10))
+	}
+	var resp struct {
+		CachesDeleted  []string
+		SpaceReclaimed int64
+	}
+	if err := c.do(ctx, http.MethodPost, "/build/prune", query, &resp); err != nil {
+		return nil, err
+	}
+	return &PruneReport{Deleted: resp.CachesDeleted, SpaceReclaimed: resp.SpaceReclaimed}, nil
+}
+
+// RemoveImage removes an image by ID or untags it by reference
+func (c *Client) RemoveImage(ctx context.Context, ref string) error {
+	return c.do(ctx, http.MethodDelete, "/images/"+ref, nil, nil)
+}
+
+// RemoveVolume removes a volume by name
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(name), nil, nil)
+}
+
+// do performs an API request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, out interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the Docker daemon at %s: %w", c.host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("docker API %s %s: %s", method, path, apiErr.Message)
+		}
+		return fmt.Errorf("docker API %s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,233 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newFakeDaemon serves handler on a temporary unix socket and returns a
+// client connected to it
+func newFakeDaemon(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not available")
+	}
+	// Socket paths are limited to about 100 bytes, so t.TempDir may be too long
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	c, err := NewClientWithHost("unix://" + socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// respond returns a handler that checks the method and writes body as JSON
+func respond(t *testing.T, method string, body any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			t.Errorf("%s %s: want method %s", r.Method, r.URL.Path, method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if body != nil {
+			_ = json.NewEncoder(w).Encode(body)
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+	c := newFakeDaemon(t, mux)
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/system/df", respond(t, http.MethodGet, map[string]any{
+		"LayersSize": 3000,
+		"Images": []map[string]any{
+			{"Id": "sha256:aaa", "RepoTags": []string{"app:v1"}, "Size": 2000, "SharedSize": 500, "Containers": 1},
+			{"Id": "sha256:bbb", "RepoTags": []string{"<none>:<none>"}, "Size": 1000, "Containers": 0},
+		},
+		"Containers": []map[string]any{
+			{"Id": "c1", "Names": []string{"/web"}, "Image": "app:v1", "State": "running", "SizeRw": 10},
+		},
+		"Volumes": []map[string]any{
+			{"Name": "data", "Driver": "local", "UsageData": map[string]any{"Size": 400, "RefCount": 0}},
+		},
+		"BuildCache": []map[string]any{
+			{"ID": "b1", "Type": "regular", "Size": 200, "InUse": false},
+		},
+	}))
+	c := newFakeDaemon(t, mux)
+
+	du, err := c.DiskUsage(context.Background())
+	if err != nil {
+		t.Fatalf("DiskUsage: %v", err)
+	}
+	if du.LayersSize != 3000 || len(du.Images) != 2 || len(du.Containers) != 1 || len(du.Volumes) != 1 || len(du.BuildCache) != 1 {
+		t.Fatalf("DiskUsage = %+v", du)
+	}
+	if img := du.Images[0]; img.ID != "sha256:aaa" || img.SharedSize != 500 || img.Containers != 1 || img.IsDangling() {
+		t.Errorf("image 0 = %+v", img)
+	}
+	if !du.Images[1].IsDangling() {
+		t.Errorf("image 1 should be dangling")
+	}
+	if ctr := du.Containers[0]; ctr.Image != "app:v1" || ctr.Names[0] != "/web" {
+		t.Errorf("container = %+v", ctr)
+	}
+	if v := du.Volumes[0]; v.UsageData == nil || v.UsageData.Size != 400 {
+		t.Errorf("volume = %+v", v)
+	}
+	if b := du.BuildCache[0]; b.ID != "b1" || b.Size != 200 {
+		t.Errorf("build cache = %+v", b)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	var query string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/prune", respond(t, http.MethodPost, map[string]any{
+		"ContainersDeleted": []string{"c1", "c2"}, "SpaceReclaimed": 10,
+	}))
+	mux.HandleFunc("/images/prune", respond(t, http.MethodPost, map[string]any{
+		"ImagesDeleted": []map[string]string{
+			{"Untagged": "app:v1"},
+			{"Deleted": "sha256:aaa"},
+		},
+		"SpaceReclaimed": 2000,
+	}))
+	mux.HandleFunc("/volumes/prune", respond(t, http.MethodPost, map[string]any{
+		"VolumesDeleted": []string{"data"}, "SpaceReclaimed": 400,
+	}))
+	mux.HandleFunc("/networks/prune", respond(t, http.MethodPost, map[string]any{
+		"NetworksDeleted": []string{"net1"},
+	}))
+	buildPrune := respond(t, http.MethodPost, map[string]any{
+		"CachesDeleted": []string{"b1"}, "SpaceReclaimed": 200,
+	})
+	mux.HandleFunc("/build/prune", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		buildPrune(w, r)
+	})
+	c := newFakeDaemon(t, mux)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		prune func() (*PruneReport, error)
+		want  PruneReport
+	}{
+		{"containers", func() (*PruneReport, error) { return c.PruneContainers(ctx, nil) },
+			PruneReport{Deleted: []string{"c1", "c2"}, SpaceReclaimed: 10}},
+		{"images", func() (*PruneReport, error) { return c.PruneImages(ctx, Filters{}.Add("dangling", "false")) },
+			PruneReport{Deleted: []string{"sha256:aaa"}, SpaceReclaimed: 2000}},
+		{"volumes", func() (*PruneReport, error) { return c.PruneVolumes(ctx, nil) },
+			PruneReport{Deleted: []string{"data"}, SpaceReclaimed: 400}},
+		{"networks", func() (*PruneReport, error) { return c.PruneNetworks(ctx, nil) },
+			PruneReport{Deleted: []string{"net1"}}},
+		{"build cache", func() (*PruneReport, error) { return c.PruneBuildCache(ctx, true, 5000, nil) },
+			PruneReport{Deleted: []string{"b1"}, SpaceReclaimed: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prune()
+			if err != nil {
+				t.Fatalf("prune: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+	if !strings.Contains(query, "all=true") || !strings.Contains(query, "keep-storage=5000") {
+		t.Errorf("build prune query = %q", query)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	var removed []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /images/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, "image "+r.PathValue("ref"))
+		respond(t, http.MethodDelete, []map[string]string{{"Deleted": "sha256:aaa"}})(w, r)
+	})
+	mux.HandleFunc("DELETE /volumes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, "volume "+r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	c := newFakeDaemon(t, mux)
+	ctx := context.Background()
+
+	if err := c.RemoveImage(ctx, "sha256:aaa"); err != nil {
+		t.Fatalf("RemoveImage: %v", err)
+	}
+	if err := c.RemoveVolume(ctx, "data"); err != nil {
+		t.Fatalf("RemoveVolume: %v", err)
+	}
+	want := []string{"image sha256:aaa", "volume data"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message":"image is being used by running container c1"}`))
+	})
+	mux.HandleFunc("/volumes/prune", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not json", http.StatusInternalServerError)
+	})
+	c := newFakeDaemon(t, mux)
+	ctx := context.Background()
+
+	err := c.RemoveImage(ctx, "sha256:aaa")
+	if err == nil || !strings.Contains(err.Error(), "image is being used by running container c1") {
+		t.Errorf("RemoveImage error = %v, want the API message", err)
+	}
+	if _, err := c.PruneVolumes(ctx, nil); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("PruneVolumes error = %v, want the status", err)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	c, err := NewClientWithHost("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "cannot connect") {
+		t.Errorf("Ping error = %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package docker
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+// pipeConn adapts a Windows named pipe opened as a file to net.Conn
+type pipeConn struct {
+	*os.File
+}
+
+// dialPipe opens a named pipe such as \\.\pipe\docker_engine
+func dialPipe(path string) (net.Conn, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &pipeConn{File: f}, nil
+}
+
+func (p *pipeConn) LocalAddr() net.Addr  { return pipeAddr(p.Name()) }
+func (p *pipeConn) RemoteAddr() net.Addr { return pipeAddr(p.Name()) }
+
+// Deadlines are not supported on synchronous pipe handles
+func (p *pipeConn) SetDeadline(time.Time) error      { return nil }
+func (p *pipeConn) SetReadDeadline(time.Time) error  { return nil }
+func (p *pipeConn) SetWriteDeadline(time.Time) error { return nil }
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "npipe" }
+func (a pipeAddr) String() string  { return string(a) }
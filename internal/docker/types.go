@@ -0,0 +1,158 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// Filters holds Docker API filter arguments, e.g. {"until": ["24h"]}
+type Filters map[string][]string
+
+// Add appends a value to the named filter
+func (f Filters) Add(name, value string) Filters {
+	f[name] = append(f[name], value)
+	return f
+}
+
+func (f Filters) query() url.Values {
+	query := url.Values{}
+	if len(f) == 0 {
+		return query
+	}
+	data, err := json.Marshal(f)
+	if err == nil {
+		query.Set("filters", string(data))
+	}
+	return query
+}
+
+// PruneReport describes the outcome of a prune call
+type PruneReport struct {
+	Deleted        []string
+	SpaceReclaimed int64
+}
+
+// ImageSummary is an image entry from /system/df
+type ImageSummary struct {
+	ID         string `json:"Id"`
+	RepoTags   []string
+	Created    int64
+	Size       int64
+	SharedSize int64
+	Containers int64
+	Labels     map[string]string
+}
+
+// IsDangling reports whether the image has no tags
+func (i ImageSummary) IsDangling() bool {
+	for _, tag := range i.RepoTags {
+		if tag != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainerSummary is a container entry from /system/df
+type ContainerSummary struct {
+	ID     string `json:"Id"`
+	Names  []string
+	Image  string
+	State  string
+	SizeRw int64
+}
+
+// Volume is a volume entry from /system/df
+type Volume struct {
+	Name      string
+	Driver    string
+	Labels    map[string]string
+	UsageData *VolumeUsage
+}
+
+// VolumeUsage holds the size of a volume and how many containers reference it
+type VolumeUsage struct {
+	Size     int64
+	RefCount int64
+}
+
+// BuildCacheRecord is a build cache entry from /system/df
+type BuildCacheRecord struct {
+	ID          string
+	Type        string
+	Description string
+	Size        int64
+	InUse       bool
+	Shared      bool
+	LastUsedAt  *time.Time
+}
+
+// DiskUsage is the response of /system/df
+type DiskUsage struct {
+	LayersSize int64
+	Images     []ImageSummary
+	Containers []ContainerSummary
+	Volumes    []Volume
+	BuildCache []BuildCacheRecord
+}
+
+// CategoryUsage holds the total and reclaimable size of one usage category
+type CategoryUsage struct {
+	Name        string
+	Count       int
+	Size        int64
+	Reclaimable int64
+}
+
+// Categories summarizes disk usage per category in the order `docker system df` uses
+func (du *DiskUsage) Categories() []CategoryUsage {
+	images := CategoryUsage{Name: "Images", Count: len(du.Images), Size: du.LayersSize}
+	for _, img := range du.Images {
+		if img.Containers == 0 {
+			unique := img.Size
+			if img.SharedSize > 0 {
+				unique -= img.SharedSize
+			}
+			images.Reclaimable += unique
+		}
+	}
+
+	containers := CategoryUsage{Name: "Containers", Count: len(du.Containers)}
+	for _, c := range du.Containers {
+		containers.Size += c.SizeRw
+		if c.State != "running" {
+			containers.Reclaimable += c.SizeRw
+		}
+	}
+
+	volumes := CategoryUsage{Name: "Volumes", Count: len(du.Volumes)}
+	for _, v := range du.Volumes {
+		if v.UsageData == nil || v.UsageData.Size < 0 {
+			continue
+		}
+		volumes.Size += v.UsageData.Size
+		if v.UsageData.RefCount == 0 {
+			volumes.Reclaimable += v.UsageData.Size
+		}
+	}
+
+	buildCache := CategoryUsage{Name: "Build Cache", Count: len(du.BuildCache)}
+	for _, b := range du.BuildCache {
+		buildCache.Size += b.Size
+		if !b.InUse && !b.Shared {
+			buildCache.Reclaimable += b.Size
+		}
+	}
+
+	return []CategoryUsage{images, containers, volumes, buildCache}
+}
+
+// Total returns the combined size and reclaimable size across all categories
+func (du *DiskUsage) Total() (size, reclaimable int64) {
+	for _, c := range du.Categories() {
+		size += c.Size
+		reclaimable += c.Reclaimable
+	}
+	return size, reclaimable
+}
@@ -0,0 +1,152 @@
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Run kinds
+const (
+	KindClean  = "clean"
+	KindReport = "report"
+)
+
+// Entry records what happened to a single cleaner during a run
+type Entry struct {
+	Cleaner     string        `json:"cleaner"`
+	BytesBefore int64         `json:"bytes_before"`
+	BytesAfter  int64         `json:"bytes_after"`
+	Freed       int64         `json:"freed"`
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Run records a single report or clean run
+type Run struct {
+	ID        string        `json:"id"`
+	Kind      string        `json:"kind"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Entries   []Entry       `json:"entries"`
+}
+
+// NewRun creates a Run of the given kind starting now
+func NewRun(kind string) *Run {
+	return &Run{
+		ID:        NewID(),
+		Kind:      kind,
+		StartedAt: time.Now(),
+	}
+}
+
+// Add appends an entry to the run
+func (r *Run) Add(entry Entry) {
+	r.Entries = append(r.Entries, entry)
+}
+
+// Finish sets the run duration
+func (r *Run) Finish() {
+	r.Duration = time.Since(r.StartedAt)
+}
+
+// Freed returns the total bytes freed by the run
+func (r *Run) Freed() int64 {
+	var total int64
+	for _, e := range r.Entries {
+		total += e.Freed
+	}
+	return total
+}
+
+// Store is an append-only JSON lines file of runs
+type Store struct {
+	path string
+}
+
+// DefaultPath returns the history file location in the user config directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "clearance", "history.jsonl"), nil
+}
+
+// NewStore creates a Store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// NewDefaultStore creates a Store at the default location
+func NewDefaultStore() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(path), nil
+}
+
+// Path returns the location of the history file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append records a run
+func (s *Store) Append(run *Run) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load returns all recorded runs, oldest first. Malformed lines are skipped.
+func (s *Store) Load() ([]Run, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+// NewID returns a short random run identifier
+func NewID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
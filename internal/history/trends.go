@@ -0,0 +1,89 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// Trend summarizes how a cache has changed across recorded runs
+type Trend struct {
+	Cleaner string
+	// Runs is the number of runs that measured the cache
+	Runs int
+	// Cleans is the number of clean runs
+	Cleans int
+	// LastSize is the most recently measured size in bytes
+	LastSize int64
+	// LastSeen is when the cache was last measured
+	LastSeen time.Time
+	// LastCleaned is when the cache was last cleaned, zero if never
+	LastCleaned time.Time
+	// TotalFreed is the cumulative space reclaimed in bytes
+	TotalFreed int64
+	// LastFreed is the space reclaimed by the most recent clean in bytes
+	LastFreed int64
+	// GrowthPerDay is the average rate the cache regrows between runs in bytes per day
+	GrowthPerDay float64
+}
+
+// Trends computes a Trend per cleaner from runs ordered oldest first
+func Trends(runs []Run) []Trend {
+	type state struct {
+		trend    Trend
+		lastSize int64
+		lastTime time.Time
+		grown    int64
+		elapsed  time.Duration
+	}
+	states := make(map[string]*state)
+
+	for _, run := range runs {
+		for _, e := range run.Entries {
+			st, ok := states[e.Cleaner]
+			if !ok {
+				st = &state{trend: Trend{Cleaner: e.Cleaner}, lastSize: -1}
+				states[e.Cleaner] = st
+			}
+			if e.BytesBefore < 0 {
+				continue
+			}
+
+			// Regrowth is measured from the size left by the previous run to the
+			// size found at the start of this one
+			if st.lastSize >= 0 && e.BytesBefore > st.lastSize {
+				st.grown += e.BytesBefore - st.lastSize
+			}
+			if st.lastSize >= 0 {
+				st.elapsed += run.StartedAt.Sub(st.lastTime)
+			}
+
+			st.trend.Runs++
+			st.trend.LastSeen = run.StartedAt
+			st.lastTime = run.StartedAt
+			st.lastSize = e.BytesBefore
+			if run.Kind == KindClean {
+				st.trend.Cleans++
+				st.trend.TotalFreed += e.Freed
+				st.trend.LastFreed = e.Freed
+				st.trend.LastCleaned = run.StartedAt
+				if e.BytesAfter >= 0 {
+					st.lastSize = e.BytesAfter
+				}
+			}
+			st.trend.LastSize = st.lastSize
+		}
+	}
+
+	trends := make([]Trend, 0, len(states))
+	for _, st := range states {
+		if st.trend.Runs == 0 {
+			continue
+		}
+		if days := st.elapsed.Hours() / 24; days > 0 {
+			st.trend.GrowthPerDay = float64(st.grown) / days
+		}
+		trends = append(trends, st.trend)
+	}
+	sort.Slice(trends, func(i, j int) bool { return trends[i].Cleaner < trends[j].Cleaner })
+	return trends
+}
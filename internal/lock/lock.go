@@ -0,0 +1,134 @@
+// Package lock provides advisory file locks that keep clearance processes
+// from cleaning at the same time
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned by TryAcquire when another process holds the lock
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is a held file lock
+type Lock struct {
+	f *os.File
+}
+
+// DefaultPath returns the path of the named lock in the user config
+// directory, e.g. ~/.config/clearance/clean.lock on Linux
+func DefaultPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "clearance", name+".lock"), nil
+}
+
+// SharedPath returns the path of the named lock in a directory every user of
+// the machine shares, e.g. /tmp/clearance-locks/clean.lock on Linux, so that
+// runs by different users exclude each other: a watch running as a service
+// account and a manual clean both prune the same container engine. The
+// directory is created on first use, writable by everyone but, where
+// supported, sticky.
+func SharedPath(name string) (string, error) {
+	dir := filepath.Join(os.TempDir(), "clearance-locks")
+	if runtime.GOOS == "windows" {
+		// The temporary directory is per user on Windows
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			dir = filepath.Join(programData, "clearance", "locks")
+		}
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0o777); err != nil {
+			return "", err
+		}
+		// Only the creator can change the mode, which the umask narrowed
+		_ = os.Chmod(dir, 0o777|os.ModeSticky)
+	}
+	return filepath.Join(dir, name+".lock"), nil
+}
+
+// CleanerName returns the lock name for a single cleaner's cache, e.g.
+// "cleaner-npm"
+func CleanerName(cleaner string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, cleaner)
+	return "cleaner-" + name
+}
+
+// TryAcquire takes the lock at path without waiting, returning ErrLocked if
+// another process holds it. The lock is released when the process exits,
+// even if it crashes.
+func TryAcquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// Symbolic links are not followed, so a link planted in a shared lock
+	// directory cannot make the holder truncate another file
+	f, err := os.OpenFile(path, os.O_RDWR|noFollow, 0)
+	if os.IsNotExist(err) {
+		f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL|noFollow, 0o644)
+		if err == nil {
+			// Let other users take shared locks too
+			_ = f.Chmod(0o666)
+		} else if os.IsExist(err) {
+			f, err = os.OpenFile(path, os.O_RDWR|noFollow, 0)
+		}
+	}
+	writable := err == nil
+	if os.IsPermission(err) {
+		// Another user created the lock file; reading is enough to lock it
+		f, err = os.OpenFile(path, os.O_RDONLY|noFollow, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Record the holder for Holder
+	if writable {
+		if err := f.Truncate(0); err == nil {
+			_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+		}
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	if err := unlockFile(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// Holder returns the process ID recorded by the process that last took the
+// lock at path, or 0 if unknown
+func Holder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
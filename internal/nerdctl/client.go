@@ -0,0 +1,426 @@
+package nerdctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// Client drives containerd through the nerdctl CLI, presenting the same
+// data model as the Docker Engine API client
+type Client struct {
+	path      string
+	namespace string
+}
+
+// NewClient creates a Client for the given containerd namespace
+func NewClient(namespace string) (*Client, error) {
+	path, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, fmt.Errorf("nerdctl not found in PATH")
+	}
+	return &Client{path: path, namespace: namespace}, nil
+}
+
+// Host returns the containerd namespace the client operates on
+func (c *Client) Host() string {
+	return "namespace " + c.namespace
+}
+
+// Ping checks whether containerd is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_, err := c.run(ctx, "info", "--format", "{{json .}}")
+	return err
+}
+
+// DiskUsage collects images, containers and volumes into the Docker data model
+func (c *Client) DiskUsage(ctx context.Context) (*docker.DiskUsage, error) {
+	du := &docker.DiskUsage{}
+
+	images, digests, err := c.images(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, mounts, err := c.containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	du.Containers = containers
+
+	for i := range images {
+		for _, ctr := range containers {
+			if usesImage(ctr.Image, images[i], digests[images[i].ID]) {
+				images[i].Containers++
+			}
+		}
+		du.LayersSize += max(images[i].Size, 0)
+	}
+	du.Images = images
+
+	volumes, err := c.volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range volumes {
+		volumes[i].UsageData.RefCount = int64(mounts[volumes[i].Name])
+	}
+	du.Volumes = volumes
+
+	return du, nil
+}
+
+// PruneContainers removes stopped containers
+func (c *Client) PruneContainers(ctx context.Context, _ docker.Filters) (*docker.PruneReport, error) {
+	return c.prune(ctx, "container", "prune", "--force")
+}
+
+// PruneNetworks removes unused networks
+func (c *Client) PruneNetworks(ctx context.Context, _ docker.Filters) (*docker.PruneReport, error) {
+	return c.prune(ctx, "network", "prune", "--force")
+}
+
+// PruneBuildCache removes BuildKit cache. nerdctl has no equivalent of
+// keep-storage, so keepStorage is ignored.
+func (c *Client) PruneBuildCache(ctx context.Context, all bool, _ int64, _ docker.Filters) (*docker.PruneReport, error) {
+	args := []string{"builder", "prune", "--force"}
+	if all {
+		args = append(args, "--all")
+	}
+	return c.prune(ctx, args...)
+}
+
+// RemoveImage removes an image by ID or untags it by reference
+func (c *Client) RemoveImage(ctx context.Context, ref string) error {
+	_, err := c.run(ctx, "image", "rm", ref)
+	return err
+}
+
+// RemoveVolume removes a volume by name
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	_, err := c.run(ctx, "volume", "rm", name)
+	return err
+}
+
+// run executes nerdctl in the client's namespace and returns its stdout
+func (c *Client) run(ctx context.Context, args ...string) ([]byte, error) {
+	args = append([]string{"--namespace", c.namespace}, args...)
+	cmd := exec.CommandContext(ctx, c.path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	audit.FromContext(ctx).Command(cmd, err)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("nerdctl %s: %s", args[2], msg)
+		}
+		return nil, fmt.Errorf("nerdctl %s: %w", args[2], err)
+	}
+	return out, nil
+}
+
+// prune runs a prune command and counts the removed items it lists
+func (c *Client) prune(ctx context.Context, args ...string) (*docker.PruneReport, error) {
+	out, err := c.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	report := &docker.PruneReport{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasSuffix(line, ":") || strings.HasPrefix(line, "Total reclaimed space") {
+			continue
+		}
+		report.Deleted = append(report.Deleted, line)
+	}
+	return report, nil
+}
+
+// jsonLines decodes one JSON object per line into fn
+func jsonLines(out []byte, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// usesImage reports whether a container started from ref runs img, whose
+// manifest digests are given. Containers may refer to an image by full or
+// short ID, by a tag with or without the registry, or by digest.
+func usesImage(ref string, img docker.ImageSummary, digests []string) bool {
+	if name, digest, ok := strings.Cut(ref, "@"); ok {
+		if slices.Contains(digests, digest) {
+			return true
+		}
+		ref = name
+	}
+
+	id := strings.TrimPrefix(img.ID, "sha256:")
+	short := strings.TrimPrefix(ref, "sha256:")
+	if len(short) >= 12 && strings.Trim(short, "0123456789abcdef") == "" && strings.HasPrefix(id, short) {
+		return true
+	}
+
+	ref = normalizeRef(ref)
+	for _, tag := range img.RepoTags {
+		if normalizeRef(tag) == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRef expands a reference to the form with registry and tag, e.g.
+// "nginx" to "docker.io/library/nginx:latest"
+func normalizeRef(ref string) string {
+	name := ref
+	// A colon after the last slash separates the tag, before it the port
+	if i := strings.LastIndex(ref, ":"); i <= strings.LastIndex(ref, "/") {
+		ref += ":latest"
+	} else {
+		name = ref[:i]
+	}
+	first, _, ok := strings.Cut(name, "/")
+	if !ok {
+		return "docker.io/library/" + ref
+	}
+	if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return "docker.io/" + ref
+	}
+	return ref
+}
+
+// images lists images, merging the per-tag rows nerdctl prints into one entry
+// per image. It also returns each image's manifest digests by ID.
+func (c *Client) images(ctx context.Context) ([]docker.ImageSummary, map[string][]string, error) {
+	out, err := c.run(ctx, "image", "ls", "--no-trunc", "--format", "{{json .}}")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []docker.ImageSummary
+	index := make(map[string]int)
+	digests := make(map[string][]string)
+	err = jsonLines(out, func(line []byte) error {
+		var row struct {
+			ID         string
+			Repository string
+			Tag        string
+			Digest     string
+			CreatedAt  string
+			Size       string
+		}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return err
+		}
+
+		i, ok := index[row.ID]
+		if !ok {
+			img := docker.ImageSummary{ID: row.ID, Size: parseSize(row.Size)}
+			if created, err := time.Parse("2006-01-02 15:04:05 -0700 MST", row.CreatedAt); err == nil {
+				img.Created = created.Unix()
+			}
+			images = append(images, img)
+			i = len(images) - 1
+			index[row.ID] = i
+		}
+		if row.Repository != "" && row.Repository != "<none>" {
+			tag := row.Tag
+			if tag == "" || tag == "<none>" {
+				tag = "latest"
+			}
+			images[i].RepoTags = append(images[i].RepoTags, row.Repository+":"+tag)
+		}
+		if row.Digest != "" && row.Digest != "<none>" && !slices.Contains(digests[row.ID], row.Digest) {
+			digests[row.ID] = append(digests[row.ID], row.Digest)
+		}
+		return nil
+	})
+	if err != nil || len(images) == 0 {
+		return images, digests, err
+	}
+	if err := c.imageLabels(ctx, images); err != nil {
+		return nil, nil, err
+	}
+	return images, digests, nil
+}
+
+// imageLabels fills in the labels of images, which image ls does not print,
+// so that label protection applies to them
+func (c *Client) imageLabels(ctx context.Context, images []docker.ImageSummary) error {
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+	}
+	out, err := c.run(ctx, append([]string{"image", "inspect"}, ids...)...)
+	if err != nil {
+		return err
+	}
+	var inspected []struct {
+		ID     string `json:"Id"`
+		Config struct {
+			Labels map[string]string
+		}
+	}
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return fmt.Errorf("nerdctl image inspect: %w", err)
+	}
+	labels := make(map[string]map[string]string)
+	for _, img := range inspected {
+		labels[img.ID] = img.Config.Labels
+	}
+	for i := range images {
+		l, ok := labels[images[i].ID]
+		if !ok {
+			// Without its labels the image cannot be checked for protection
+			return fmt.Errorf("nerdctl image inspect: no result for image %s", images[i].ID)
+		}
+		images[i].Labels = l
+	}
+	return nil
+}
+
+// containers lists all containers and counts how many use each named volume
+func (c *Client) containers(ctx context.Context) ([]docker.ContainerSummary, map[string]int, error) {
+	out, err := c.run(ctx, "container", "ls", "--all", "--no-trunc", "--format", "{{json .}}")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var containers []docker.ContainerSummary
+	var ids []string
+	err = jsonLines(out, func(line []byte) error {
+		var row struct {
+			ID     string
+			Image  string
+			Names  string
+			Status string
+		}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return err
+		}
+		state := "exited"
+		if strings.HasPrefix(row.Status, "Up") {
+			state = "running"
+		} else if strings.HasPrefix(row.Status, "Created") {
+			state = "created"
+		}
+		containers = append(containers, docker.ContainerSummary{
+			ID:    row.ID,
+			Names: []string{row.Names},
+			Image: row.Image,
+			State: state,
+		})
+		ids = append(ids, row.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mounts := make(map[string]int)
+	if len(ids) == 0 {
+		return containers, mounts, nil
+	}
+	out, err = c.run(ctx, append([]string{"container", "inspect"}, ids...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var inspected []struct {
+		Mounts []struct {
+			Type string
+			Name string
+		}
+	}
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return nil, nil, err
+	}
+	for _, ctr := range inspected {
+		for _, m := range ctr.Mounts {
+			if m.Type == "volume" {
+				mounts[m.Name]++
+			}
+		}
+	}
+	return containers, mounts, nil
+}
+
+// volumes lists volumes with their sizes and labels
+func (c *Client) volumes(ctx context.Context) ([]docker.Volume, error) {
+	out, err := c.run(ctx, "volume", "ls", "--size", "--format", "{{json .}}")
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []docker.Volume
+	err = jsonLines(out, func(line []byte) error {
+		var row struct {
+			Name   string
+			Driver string
+			Labels string
+			Size   string
+		}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return err
+		}
+		v := docker.Volume{Name: row.Name, Driver: row.Driver, Labels: parseLabels(row.Labels)}
+		v.UsageData = &docker.VolumeUsage{Size: parseSize(row.Size)}
+		volumes = append(volumes, v)
+		return nil
+	})
+	return volumes, err
+}
+
+// parseLabels parses nerdctl's "k1=v1,k2=v2" label format
+func parseLabels(s string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		labels[key] = value
+	}
+	return labels
+}
+
+// parseSize parses human-readable sizes such as "77.8 MiB", returning -1 when unknown
+func parseSize(s string) int64 {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		if exp := strings.IndexByte("KMGTPE", s[n-1]); exp >= 0 {
+			for i := 0; i <= exp; i++ {
+				multiplier *= 1024
+			}
+			s = s[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return -1
+	}
+	return int64(value * multiplier)
+}
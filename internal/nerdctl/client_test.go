@@ -0,0 +1,109 @@
+package nerdctl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/abdorrahmani/clearance/internal/docker"
+)
+
+// fakeNerdctl answers the commands DiskUsage runs with canned output
+const fakeNerdctl = `#!/bin/sh
+# $1 $2 are --namespace <name>
+case "$3 $4" in
+"image ls")
+	echo '{"ID":"sha256:aaaaaaaaaaaaaaaa","Repository":"app","Tag":"v1","Digest":"sha256:d1","Size":"10 MiB"}'
+	echo '{"ID":"sha256:aaaaaaaaaaaaaaaa","Repository":"app","Tag":"v2","Digest":"sha256:d1","Size":"10 MiB"}'
+	echo '{"ID":"sha256:bbbbbbbbbbbbbbbb","Repository":"<none>","Tag":"<none>","Size":"1 KiB"}'
+	;;
+"image inspect")
+	echo '[{"Id":"sha256:aaaaaaaaaaaaaaaa","Config":{"Labels":{"keep":"true"}}},{"Id":"sha256:bbbbbbbbbbbbbbbb","Config":{}}]'
+	;;
+"container ls")
+	echo '{"ID":"c1","Image":"docker.io/library/app:v2","Names":"web","Status":"Up 2 hours"}'
+	;;
+"container inspect")
+	echo '[{"Mounts":[{"Type":"volume","Name":"data"}]}]'
+	;;
+"volume ls")
+	echo '{"Name":"data","Driver":"local","Labels":"keep=true,team=db","Size":"2 KiB"}'
+	;;
+*)
+	echo "unexpected: $*" >&2
+	exit 1
+	;;
+esac
+`
+
+func newFakeClient(t *testing.T) *Client {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake nerdctl is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "nerdctl")
+	if err := os.WriteFile(path, []byte(fakeNerdctl), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &Client{path: path, namespace: "default"}
+}
+
+func TestDiskUsage(t *testing.T) {
+	du, err := newFakeClient(t).DiskUsage(context.Background())
+	if err != nil {
+		t.Fatalf("DiskUsage: %v", err)
+	}
+	if len(du.Images) != 2 {
+		t.Fatalf("got %d images, want 2", len(du.Images))
+	}
+
+	app := du.Images[0]
+	if app.ID != "sha256:aaaaaaaaaaaaaaaa" || len(app.RepoTags) != 2 {
+		t.Errorf("app image = %+v", app)
+	}
+	if app.Labels["keep"] != "true" {
+		t.Errorf("app image labels = %v, want keep=true", app.Labels)
+	}
+	if app.Containers != 1 {
+		t.Errorf("app image used by %d containers, want 1", app.Containers)
+	}
+	if db := du.Images[1]; !db.IsDangling() || db.Containers != 0 || len(db.Labels) != 0 {
+		t.Errorf("db image = %+v", db)
+	}
+
+	if len(du.Volumes) != 1 || du.Volumes[0].Labels["team"] != "db" || du.Volumes[0].UsageData.RefCount != 1 {
+		t.Errorf("volumes = %+v", du.Volumes)
+	}
+}
+
+func TestUsesImage(t *testing.T) {
+	img := docker.ImageSummary{
+		ID:       "sha256:0123456789abcdef0123",
+		RepoTags: []string{"nginx:latest", "ghcr.io/a/b:v1", "localhost:5000/x:latest"},
+	}
+	digests := []string{"sha256:d1"}
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{"nginx", true},
+		{"docker.io/library/nginx:latest", true},
+		{"nginx:1", false},
+		{"redis", false},
+		{"nginx@sha256:d1", true},
+		{"other@sha256:d1", true},
+		{"0123456789ab", true},
+		{"sha256:0123456789abcdef0123", true},
+		{"0123", false},
+		{"ghcr.io/a/b:v1", true},
+		{"ghcr.io/a/b", false},
+		{"localhost:5000/x", true},
+	}
+	for _, tt := range tests {
+		if got := usesImage(tt.ref, img, digests); got != tt.want {
+			t.Errorf("usesImage(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
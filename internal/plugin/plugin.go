@@ -0,0 +1,276 @@
+// Package plugin runs external cleaners.
+//
+// A plugin is an executable named clearance-cleaner-<name> on PATH. For
+// every call clearance starts the plugin, writes a single JSON Request to
+// its stdin and reads a single JSON response from its stdout:
+//
+//	describe  -> Description
+//	size      -> SizeResponse
+//	plan      -> PlanResponse
+//	clean     -> CleanResponse
+//
+// Any response may set "error" to report a failure. Anything written to
+// stderr is shown to the user as progress output.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/audit"
+)
+
+// Protocol is the protocol version spoken by clearance
+const Protocol = 1
+
+// Prefix is the file name prefix of plugin executables
+const Prefix = "clearance-cleaner-"
+
+// Verbs
+const (
+	VerbDescribe = "describe"
+	VerbSize     = "size"
+	VerbPlan     = "plan"
+	VerbClean    = "clean"
+)
+
+// Request is sent to the plugin on stdin
+type Request struct {
+	Protocol int    `json:"protocol"`
+	Verb     string `json:"verb"`
+}
+
+// Description is the response to describe
+type Description struct {
+	Protocol    int    `json:"protocol"`
+	Description string `json:"description"`
+	Icon        string `json:"icon,omitempty"`
+	// InAll marks the cleaner as selected by "all"
+	InAll bool   `json:"in_all,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// SizeResponse is the response to size
+type SizeResponse struct {
+	// Bytes is the cache size, or -1 if it cannot be measured
+	Bytes int64 `json:"bytes"`
+	// Status replaces the size in the report, e.g. "Not installed"
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PlanItem is a single target in a PlanResponse
+type PlanItem struct {
+	Target string `json:"target"`
+	// Size is the size in bytes, or -1 if unknown
+	Size   int64  `json:"size"`
+	Remove bool   `json:"remove"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PlanResponse is the response to plan
+type PlanResponse struct {
+	Items []PlanItem `json:"items"`
+	Error string     `json:"error,omitempty"`
+}
+
+// CleanResponse is the response to clean
+type CleanResponse struct {
+	// Freed is the space reclaimed in bytes, or -1 if unknown
+	Freed int64  `json:"freed"`
+	Error string `json:"error,omitempty"`
+}
+
+// Plugin is an external cleaner executable
+type Plugin struct {
+	// Name is the executable name without the prefix, e.g. "foo"
+	Name string
+	// Path is the location of the executable
+	Path string
+	// Stderr receives the plugin's progress output, defaults to os.Stdout
+	Stderr io.Writer
+}
+
+// Find returns the plugins on the given PATH list, sorted by name. If a name
+// appears in several directories the first one wins, as it would for a shell.
+func Find(pathList string) []*Plugin {
+	seen := make(map[string]bool)
+	var plugins []*Plugin
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, &Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the plugin name for an executable file name
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(file))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	if !strings.HasPrefix(file, Prefix) || len(file) == len(Prefix) {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimPrefix(file, Prefix)), true
+}
+
+// isExecutable reports whether path is a regular file that can be run
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// Describe asks the plugin to describe itself and checks its protocol version
+func (p *Plugin) Describe(ctx context.Context, timeout time.Duration) (*Description, error) {
+	var d Description
+	if err := p.call(ctx, VerbDescribe, timeout, &d, &d.Error); err != nil {
+		return nil, err
+	}
+	if d.Protocol != Protocol {
+		return nil, fmt.Errorf("plugin %s speaks protocol %d, expected %d", p.Name, d.Protocol, Protocol)
+	}
+	return &d, nil
+}
+
+// Size asks the plugin for the size of its cache
+func (p *Plugin) Size(ctx context.Context, timeout time.Duration) (*SizeResponse, error) {
+	r := SizeResponse{Bytes: -1}
+	if err := p.call(ctx, VerbSize, timeout, &r, &r.Error); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Plan asks the plugin what clean would remove
+func (p *Plugin) Plan(ctx context.Context, timeout time.Duration) (*PlanResponse, error) {
+	var r PlanResponse
+	if err := p.call(ctx, VerbPlan, timeout, &r, &r.Error); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Clean asks the plugin to clean its cache
+func (p *Plugin) Clean(ctx context.Context, timeout time.Duration) (*CleanResponse, error) {
+	r := CleanResponse{Freed: -1}
+	if err := p.call(ctx, VerbClean, timeout, &r, &r.Error); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// call runs the plugin for a single verb, decoding its response into out.
+// errField points at the response's error field.
+func (p *Plugin) call(ctx context.Context, verb string, timeout time.Duration, out interface{}, errField *string) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	request, err := json.Marshal(Request{Protocol: Protocol, Verb: verb})
+	if err != nil {
+		return err
+	}
+
+	stderr := p.Stderr
+	if stderr == nil {
+		stderr = os.Stdout
+	}
+	progress := &prefixWriter{prefix: "[" + p.Name + "] ", w: stderr}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, verb)
+	cmd.Stdin = bytes.NewReader(append(request, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = progress
+	// Don't wait forever for pipes held open by processes the plugin started
+	cmd.WaitDelay = 2 * time.Second
+
+	err = cmd.Run()
+	progress.Flush()
+	audit.FromContext(ctx).Command(cmd, err)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("plugin %s timed out after %s running %s", p.Name, timeout, verb)
+	}
+	if err != nil {
+		// A plugin may exit non-zero after reporting the error in its response
+		if json.Unmarshal(stdout.Bytes(), out) == nil && *errField != "" {
+			return fmt.Errorf("plugin %s: %s", p.Name, *errField)
+		}
+		return fmt.Errorf("plugin %s failed running %s: %w", p.Name, verb, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("plugin %s returned an invalid %s response: %w", p.Name, verb, err)
+	}
+	if *errField != "" {
+		return fmt.Errorf("plugin %s: %s", p.Name, *errField)
+	}
+	return nil
+}
+
+// prefixWriter writes each line with a prefix
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	buf    []byte
+}
+
+func (pw *prefixWriter) Write(b []byte) (int, error) {
+	pw.buf = append(pw.buf, b...)
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if _, err := fmt.Fprintf(pw.w, "%s%s\n", pw.prefix, bytes.TrimRight(pw.buf[:i], "\r")); err != nil {
+			return 0, err
+		}
+		pw.buf = pw.buf[i+1:]
+	}
+}
+
+// Flush writes any incomplete last line
+func (pw *prefixWriter) Flush() {
+	if len(pw.buf) > 0 {
+		fmt.Fprintf(pw.w, "%s%s\n", pw.prefix, pw.buf)
+		pw.buf = nil
+	}
+}
@@ -0,0 +1,26 @@
+package reporter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// VolumeOf returns the capacity and free space of the file system holding
+// path, or the closest existing directory above it if path does not exist
+func VolumeOf(path string) (Volume, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return Volume{}, err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return volumeAt(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Volume{}, errors.New("no existing directory above " + path)
+		}
+		dir = parent
+	}
+}
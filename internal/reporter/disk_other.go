@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package reporter
+
+import "errors"
+
+// volumeAt is not supported on this platform
+func volumeAt(path string) (Volume, error) {
+	return Volume{}, errors.New("file system capacity is not supported on this platform")
+}
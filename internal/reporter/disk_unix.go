@@ -0,0 +1,53 @@
+//go:build linux || darwin || freebsd
+
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// volumeAt returns the capacity of the file system holding path, which must
+// exist, and its mount point
+func volumeAt(path string) (Volume, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return Volume{}, err
+	}
+	return Volume{
+		Path:  mountPoint(path),
+		Total: int64(st.Blocks) * int64(st.Bsize),
+		Free:  int64(st.Bavail) * int64(st.Bsize),
+	}, nil
+}
+
+// mountPoint walks up from path while the parent is on the same device
+func mountPoint(path string) string {
+	dev := func(p string) (uint64, bool) {
+		info, err := os.Stat(p)
+		if err != nil {
+			return 0, false
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return 0, false
+		}
+		return uint64(st.Dev), true
+	}
+
+	current, ok := dev(path)
+	if !ok {
+		return path
+	}
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		if d, ok := dev(parent); !ok || d != current {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,21 @@
+package reporter
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// volumeAt returns the capacity of the drive or share holding path
+func volumeAt(path string) (Volume, error) {
+	root := filepath.VolumeName(path) + `\`
+	name, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return Volume{}, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &free, &total, &totalFree); err != nil {
+		return Volume{}, err
+	}
+	return Volume{Path: root, Total: int64(total), Free: int64(free)}, nil
+}
@@ -0,0 +1,325 @@
+package reporter
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+)
+
+// Report export formats
+const (
+	FormatTable    = "table"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// WriteMarkdown writes the report as Markdown tables, e.g. for a ticket
+func WriteMarkdown(w io.Writer, report *Report) error {
+	var b strings.Builder
+	b.WriteString("# Clearance cache report\n\n")
+	fmt.Fprintf(&b, "Generated %s\n\n", report.GeneratedAt.Format("2006-01-02 15:04"))
+
+	b.WriteString("| Cache | Size | Disk | Volume | Description |\n")
+	b.WriteString("|-------|-----:|-----:|--------|-------------|\n")
+	for _, row := range report.Rows {
+		name := row.Name
+		if row.Part != "" {
+			name = "↳ " + name
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(name), markdownCell(row.Status),
+			row.FormatShare(), markdownCell(row.Volume), markdownCell(row.Label))
+	}
+	fmt.Fprintf(&b, "| **Total** | **%s** | | | |\n", formatSize(report.Total))
+
+	if len(report.Volumes) > 0 {
+		b.WriteString("\n| Volume | Free | Capacity | Used |\n")
+		b.WriteString("|--------|-----:|---------:|-----:|\n")
+		for _, v := range report.Volumes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %.0f%% |\n", markdownCell(v.Path), formatSize(v.Free), formatSize(v.Total), v.UsedPercent())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes s for use in a table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// WriteHTML writes the report as a self-contained page with the cache table,
+// a treemap of the caches and a chart of their size across runs, oldest
+// first
+func WriteHTML(w io.Writer, report *Report, runs []history.Run) error {
+	data := struct {
+		Report    *Report
+		Generated string
+		Total     string
+		Treemap   template.HTML
+		History   template.HTML
+	}{
+		Report:    report,
+		Generated: report.GeneratedAt.Format("2006-01-02 15:04"),
+		Total:     formatSize(report.Total),
+		Treemap:   treemapSVG(report),
+		History:   historySVG(report, runs),
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size": formatSize,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Clearance cache report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+h1 { font-size: 1.6em; } h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+th { background: #f4f4f4; }
+td.num, th.num { text-align: right; white-space: nowrap; }
+tr.part td:first-child { padding-left: 2em; color: #555; }
+tr.total td { font-weight: bold; border-top: 2px solid #999; }
+.missing { color: #a60; } .error { color: #c00; }
+.meta { color: #666; }
+svg text { font-size: 12px; fill: #222; }
+</style>
+</head>
+<body>
+<h1>Clearance cache report</h1>
+<p class="meta">Generated {{.Generated}}</p>
+
+<h2>Caches</h2>
+<table>
+<tr><th>Cache</th><th class="num">Size</th><th class="num">Disk</th><th>Volume</th><th>Description</th></tr>
+{{- range .Report.Rows}}
+<tr{{if .Part}} class="part"{{end}}><td>{{.Name}}</td><td class="num{{if .Failed}} error{{else if lt .Bytes 0}} missing{{end}}">{{.Status}}</td><td class="num">{{.FormatShare}}</td><td>{{.Volume}}</td><td>{{.Label}}</td></tr>
+{{- end}}
+<tr class="total"><td>Total</td><td class="num">{{.Total}}</td><td></td><td></td><td></td></tr>
+</table>
+{{- if .Report.Volumes}}
+
+<h2>Volumes</h2>
+<table>
+<tr><th>Volume</th><th class="num">Free</th><th class="num">Capacity</th><th class="num">Used</th></tr>
+{{- range .Report.Volumes}}
+<tr><td>{{.Path}}</td><td class="num">{{size .Free}}</td><td class="num">{{size .Total}}</td><td class="num">{{printf "%.0f%%" .UsedPercent}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Cache sizes</h2>
+{{.Treemap}}
+
+<h2>History</h2>
+{{.History}}
+</body>
+</html>
+`))
+
+// treemapColors are used in turn for the treemap tiles
+var treemapColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// treemapSVG draws the top-level caches as tiles sized by their size
+func treemapSVG(report *Report) template.HTML {
+	const width, height = 1000.0, 400.0
+	var rows []Row
+	for _, row := range report.Rows {
+		if row.Part == "" && row.Bytes > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return `<p class="meta">No cache data to show</p>`
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Bytes > rows[j].Bytes })
+
+	values := make([]float64, len(rows))
+	for i, row := range rows {
+		values[i] = float64(row.Bytes)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %.0f %.0f" width="100%%" role="img" aria-label="Treemap of cache sizes">`, width, height)
+	for i, r := range squarify(values, rect{0, 0, width, height}) {
+		row := rows[i]
+		label := template.HTMLEscapeString(row.Name)
+		fmt.Fprintf(&b, `<g><title>%s: %s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#fff"/>`,
+			label, formatSize(row.Bytes), r.X, r.Y, r.W, r.H, treemapColors[i%len(treemapColors)])
+		// Only label tiles large enough to hold the text
+		if r.W > 60 && r.H > 36 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f">%s</text><text x="%.1f" y="%.1f">%s</text>`,
+				r.X+6, r.Y+16, label, r.X+6, r.Y+32, formatSize(row.Bytes))
+		}
+		b.WriteString("</g>")
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+type rect struct {
+	X, Y, W, H float64
+}
+
+// squarify lays out values, sorted largest first, as tiles filling r whose
+// aspect ratios are kept close to 1
+func squarify(values []float64, r rect) []rect {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	areas := make([]float64, len(values))
+	for i, v := range values {
+		areas[i] = v / total * r.W * r.H
+	}
+
+	// worst returns the largest aspect ratio of a row of areas along side
+	worst := func(row []float64, side float64) float64 {
+		var sum, ratio float64
+		for _, a := range row {
+			sum += a
+		}
+		for _, a := range row {
+			ratio = math.Max(ratio, math.Max(side*side*a/(sum*sum), sum*sum/(side*side*a)))
+		}
+		return ratio
+	}
+
+	tiles := make([]rect, 0, len(values))
+	for len(areas) > 0 {
+		side := math.Min(r.W, r.H)
+		n := 1
+		for n < len(areas) && worst(areas[:n+1], side) <= worst(areas[:n], side) {
+			n++
+		}
+
+		var sum float64
+		for _, a := range areas[:n] {
+			sum += a
+		}
+		thickness := sum / side
+		offset := 0.0
+		for _, a := range areas[:n] {
+			length := a / thickness
+			if r.W >= r.H {
+				tiles = append(tiles, rect{r.X, r.Y + offset, thickness, length})
+			} else {
+				tiles = append(tiles, rect{r.X + offset, r.Y, length, thickness})
+			}
+			offset += length
+		}
+		if r.W >= r.H {
+			r.X, r.W = r.X+thickness, r.W-thickness
+		} else {
+			r.Y, r.H = r.Y+thickness, r.H-thickness
+		}
+		areas = areas[n:]
+	}
+	return tiles
+}
+
+// historyPoint is the combined size of the caches after a run
+type historyPoint struct {
+	Time  time.Time
+	Size  int64
+	Freed int64
+}
+
+// historyPoints replays runs, carrying the last known size of each cache
+// forward, so runs that only measure some caches do not drop the others.
+// Caches that are part of another row in the report are left out.
+func historyPoints(report *Report, runs []history.Run) []historyPoint {
+	parts := make(map[string]bool)
+	for _, row := range report.Rows {
+		if row.Part != "" {
+			parts[row.Name] = true
+		}
+	}
+
+	sizes := make(map[string]int64)
+	var points []historyPoint
+	for _, run := range runs {
+		for _, e := range run.Entries {
+			if parts[e.Cleaner] {
+				continue
+			}
+			size := e.BytesBefore
+			if run.Kind == history.KindClean && e.BytesAfter >= 0 {
+				size = e.BytesAfter
+			}
+			if size >= 0 {
+				sizes[e.Cleaner] = size
+			}
+		}
+		point := historyPoint{Time: run.StartedAt}
+		for _, size := range sizes {
+			point.Size += size
+		}
+		if run.Kind == history.KindClean {
+			point.Freed = run.Freed()
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// historySVG charts the combined cache size across runs as a line, with the
+// space freed by clean runs as bars
+func historySVG(report *Report, runs []history.Run) template.HTML {
+	const width, height, left, bottom = 1000.0, 300.0, 80.0, 30.0
+	points := historyPoints(report, runs)
+	if len(points) < 2 {
+		return `<p class="meta">Not enough recorded runs to chart yet</p>`
+	}
+
+	var top int64 = 1
+	for _, p := range points {
+		top = max(top, p.Size, p.Freed)
+	}
+	first, last := points[0].Time, points[len(points)-1].Time
+	span := last.Sub(first).Seconds()
+	x := func(t time.Time) float64 {
+		if span <= 0 {
+			return left
+		}
+		return left + t.Sub(first).Seconds()/span*(width-left-10)
+	}
+	y := func(size int64) float64 {
+		return (height - bottom) * (1 - float64(size)/float64(top))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %.0f %.0f" width="100%%" role="img" aria-label="Cache size across runs">`, width, height)
+	fmt.Fprintf(&b, `<line x1="%.0f" y1="0" x2="%.0f" y2="%.0f" stroke="#999"/><line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#999"/>`,
+		left, left, height-bottom, left, height-bottom, width, height-bottom)
+	fmt.Fprintf(&b, `<text x="%.0f" y="12" text-anchor="end">%s</text><text x="%.0f" y="%.0f" text-anchor="end">0 B</text>`,
+		left-6, formatSize(top), left-6, height-bottom)
+	fmt.Fprintf(&b, `<text x="%.0f" y="%.0f">%s</text><text x="%.0f" y="%.0f" text-anchor="end">%s</text>`,
+		left, height-8, first.Format("2006-01-02"), width-10, height-8, last.Format("2006-01-02"))
+
+	var line []string
+	for _, p := range points {
+		if p.Freed > 0 {
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="6" height="%.1f" fill="#59a14f"><title>%s: freed %s</title></rect>`,
+				x(p.Time)-3, y(p.Freed), height-bottom-y(p.Freed), p.Time.Format("2006-01-02 15:04"), formatSize(p.Freed))
+		}
+		line = append(line, fmt.Sprintf("%.1f,%.1f", x(p.Time), y(p.Size)))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#4e79a7" stroke-width="2"/>`, strings.Join(line, " "))
+	for _, p := range points {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="#4e79a7"><title>%s: %s</title></circle>`,
+			x(p.Time), y(p.Size), p.Time.Format("2006-01-02 15:04"), formatSize(p.Size))
+	}
+	b.WriteString(`</svg><p class="meta"><span style="color:#4e79a7">&#9632;</span> cache size after each run &nbsp; <span style="color:#59a14f">&#9632;</span> space freed by cleaning</p>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,94 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+)
+
+// FormatOpenMetrics is the report export format for Prometheus and the
+// node_exporter textfile collector
+const FormatOpenMetrics = "openmetrics"
+
+// OpenMetricsContentType is the content type of WriteOpenMetrics output
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// metric is a gauge family in the OpenMetrics output
+type metric struct {
+	name    string
+	unit    string
+	help    string
+	samples []string
+}
+
+func (m *metric) add(labels string, value any) {
+	m.samples = append(m.samples, fmt.Sprintf("%s{%s} %v", m.name, labels, value))
+}
+
+// WriteOpenMetrics writes the report as OpenMetrics gauges labelled by
+// cleaner. entries holds the number of entries per cleaner and trends the
+// recorded cleans; cleaners missing from either are left out of those
+// gauges. Caches that are part of another carry a part_of label, so
+// summing the gauges without them does not count anything twice.
+func WriteOpenMetrics(w io.Writer, report *Report, entries map[string]int, trends []history.Trend) error {
+	size := &metric{name: "clearance_cache_bytes", unit: "bytes", help: "Size of the cache."}
+	count := &metric{name: "clearance_cache_entries", help: "Number of entries in the cache."}
+	cleaned := &metric{name: "clearance_cache_last_cleaned_timestamp_seconds", unit: "seconds", help: "When the cache was last cleaned."}
+	freed := &metric{name: "clearance_cache_last_freed_bytes", unit: "bytes", help: "Space reclaimed by the last clean of the cache."}
+	volumeSize := &metric{name: "clearance_volume_size_bytes", unit: "bytes", help: "Capacity of a file system holding caches."}
+	volumeFree := &metric{name: "clearance_volume_free_bytes", unit: "bytes", help: "Free space on a file system holding caches."}
+	generated := &metric{name: "clearance_report_timestamp_seconds", unit: "seconds", help: "When the caches were measured."}
+
+	labels := make(map[string]string)
+	for _, row := range report.Rows {
+		l := fmt.Sprintf(`cleaner="%s"`, labelValue(row.Name))
+		if row.Part != "" {
+			l += fmt.Sprintf(`,part_of="%s"`, labelValue(row.Part))
+		}
+		labels[row.Name] = l
+		if row.Bytes >= 0 {
+			size.add(l, row.Bytes)
+		}
+		if n, ok := entries[row.Name]; ok {
+			count.add(l, n)
+		}
+	}
+	for _, t := range trends {
+		l, ok := labels[t.Cleaner]
+		if !ok || t.LastCleaned.IsZero() {
+			continue
+		}
+		cleaned.add(l, t.LastCleaned.Unix())
+		freed.add(l, t.LastFreed)
+	}
+	for _, v := range report.Volumes {
+		l := fmt.Sprintf(`volume="%s"`, labelValue(v.Path))
+		volumeSize.add(l, v.Total)
+		volumeFree.add(l, v.Free)
+	}
+	generated.samples = append(generated.samples, fmt.Sprintf("%s %d", generated.name, report.GeneratedAt.Unix()))
+
+	var b strings.Builder
+	for _, m := range []*metric{size, count, cleaned, freed, volumeSize, volumeFree, generated} {
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", m.name)
+		if m.unit != "" {
+			fmt.Fprintf(&b, "# UNIT %s %s\n", m.name, m.unit)
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.name, m.help)
+		for _, sample := range m.samples {
+			b.WriteString(sample)
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelValue escapes s for use as a label value
+func labelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
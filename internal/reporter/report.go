@@ -0,0 +1,112 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Row is the size of a single cache in a Report
+type Row struct {
+	Name  string
+	Label string
+	// Part is the name of the row this cache is part of, empty for top-level rows
+	Part string
+	// Bytes is the size in bytes, or -1 if it could not be measured
+	Bytes int64
+	// Status is the human-readable size or state, e.g. "1.2 GB" or "Not running"
+	Status string
+	// Volume is the mount point or drive the cache lives on, empty if unknown
+	Volume string
+	// Share is the percentage of the volume's capacity the cache takes, or -1
+	Share float64
+}
+
+// Volume is the capacity of a file system holding caches
+type Volume struct {
+	Path  string
+	Total int64
+	Free  int64
+}
+
+// FormatShare formats the share of a volume a cache takes, e.g. "12.5%",
+// or returns "" if it is unknown
+func (r Row) FormatShare() string {
+	switch {
+	case r.Share < 0:
+		return ""
+	case r.Share > 0 && r.Share < 0.1:
+		return "<0.1%"
+	}
+	return fmt.Sprintf("%.1f%%", r.Share)
+}
+
+// Failed reports whether the cache could not be measured because of an error
+func (r Row) Failed() bool {
+	return r.Status == "Error" || r.Status == "Error getting size"
+}
+
+// UsedPercent returns how full the volume is in percent
+func (v Volume) UsedPercent() float64 {
+	if v.Total <= 0 {
+		return 0
+	}
+	return float64(v.Total-v.Free) / float64(v.Total) * 100
+}
+
+// Report is the measured size of every cache
+type Report struct {
+	// GeneratedAt is when the caches were measured
+	GeneratedAt time.Time
+	Rows        []Row
+	// Total is the combined size of the top-level rows
+	Total   int64
+	Volumes []Volume
+}
+
+// Report sort orders
+const (
+	SortBySize = "size"
+	SortByName = "name"
+)
+
+// Sort orders the top-level rows by size, largest first, or by name. Rows
+// that are part of another follow it, ordered the same way.
+func (r *Report) Sort(by string) {
+	less := func(a, b Row) bool {
+		if by == SortBySize && a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+
+	var top []Row
+	parts := make(map[string][]Row)
+	for _, row := range r.Rows {
+		if row.Part == "" {
+			top = append(top, row)
+		} else {
+			parts[row.Part] = append(parts[row.Part], row)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return less(top[i], top[j]) })
+
+	rows := make([]Row, 0, len(r.Rows))
+	for _, row := range top {
+		rows = append(rows, row)
+		children := parts[row.Name]
+		sort.SliceStable(children, func(i, j int) bool { return less(children[i], children[j]) })
+		rows = append(rows, children...)
+		delete(parts, row.Name)
+	}
+	// Keep parts whose parent is not in the report
+	for _, row := range r.Rows {
+		if _, ok := parts[row.Part]; ok && row.Part != "" {
+			rows = append(rows, row)
+		}
+	}
+	r.Rows = rows
+
+	sort.SliceStable(r.Volumes, func(i, j int) bool { return r.Volumes[i].Path < r.Volumes[j].Path })
+}
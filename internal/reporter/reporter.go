@@ -1,39 +1,66 @@
 package reporter
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
-	"strings"
+	"time"
 )
 
+// Sizer is anything that can report the size of a cache, such as a cleaner
+type Sizer interface {
+	GetSize(ctx context.Context) (string, error)
+}
+
+// Measurer is implemented by sources that can measure their cache in bytes
+type Measurer interface {
+	Measure(ctx context.Context) (int64, error)
+}
+
+// Locator is implemented by sources whose caches are directories on disk,
+// used to find the file system they live on
+type Locator interface {
+	CachePaths() []string
+}
+
+// source is a cache included in the report
+type source struct {
+	name  string
+	label string
+	part  string
+	sizer Sizer
+}
+
 // CacheReporter handles reporting of cache sizes
-type CacheReporter struct{}
+type CacheReporter struct {
+	sources []source
+	bytes   map[string]int64
+}
 
 // NewCacheReporter creates a new CacheReporter instance
 func NewCacheReporter() *CacheReporter {
-	return &CacheReporter{}
+	return &CacheReporter{
+		bytes: make(map[string]int64),
+	}
 }
 
-// getDirSize calculates the size of a directory in bytes
-func (r *CacheReporter) getDirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	return size, err
+// AddSource includes a cache in the report under the given label
+func (r *CacheReporter) AddSource(name, label string, s Sizer) {
+	r.sources = append(r.sources, source{name: name, label: label, sizer: s})
+}
+
+// AddPartSource includes a cache that is part of the source named parent,
+// such as a single Docker cleanup mode. It is left out of the total.
+func (r *CacheReporter) AddPartSource(name, label, parent string, s Sizer) {
+	r.sources = append(r.sources, source{name: name, label: label, part: parent, sizer: s})
 }
 
 // formatSize converts bytes to human-readable format
 func (r *CacheReporter) formatSize(size int64) string {
+	return formatSize(size)
+}
+
+// formatSize converts bytes to human-readable format
+func formatSize(size int64) string {
 	const unit = 1024
 	if size < unit {
 		return fmt.Sprintf("%d B", size)
@@ -46,154 +73,68 @@ func (r *CacheReporter) formatSize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
-// GetNPMCacheSize returns the size of npm cache
-func (r *CacheReporter) GetNPMCacheSize() (string, error) {
-	npmCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "npm-cache")
-	if _, err := os.Stat(npmCache); os.IsNotExist(err) {
-		return "Not found", nil
-	}
-	size, err := r.getDirSize(npmCache)
-	if err != nil {
-		return "Error", err
-	}
-	return r.formatSize(size), nil
-}
-
-// GetYarnCacheSize returns the size of yarn cache
-func (r *CacheReporter) GetYarnCacheSize() (string, error) {
-	yarnCache := filepath.Join(os.Getenv("LOCALAPPDATA"), "Yarn", "cache", "v6")
-	if _, err := os.Stat(yarnCache); os.IsNotExist(err) {
-		return "Not found", nil
-	}
-	size, err := r.getDirSize(yarnCache)
-	if err != nil {
-		return "Error", err
-	}
-	return r.formatSize(size), nil
-}
-
-// GetDockerCacheSize returns the size of Docker cache
-func (r *CacheReporter) GetDockerCacheSize() (string, error) {
-	if _, err := exec.LookPath("docker"); err != nil {
-		return "Not installed", nil
-	}
-
-	// Check if Docker daemon is running
-	cmd := exec.Command("docker", "info")
-	if err := cmd.Run(); err != nil {
-		return "Docker not running", nil
-	}
-
-	cmd = exec.Command("docker", "system", "df", "--format", "{{.Size}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return "Error getting size", nil
-	}
-	return strings.TrimSpace(string(output)), nil
-}
-
-// GetWinSxSTempSize returns the size of WinSxS temp folder
-func (r *CacheReporter) GetWinSxSTempSize() (string, error) {
-	if runtime.GOOS != "windows" {
-		return "N/A", nil
-	}
-
-	winsxsTemp := filepath.Join(os.Getenv("WINDIR"), "WinSxS", "Temp")
-	if _, err := os.Stat(winsxsTemp); os.IsNotExist(err) {
-		return "Not found", nil
-	}
-	size, err := r.getDirSize(winsxsTemp)
-	if err != nil {
-		return "Error", err
-	}
-	return r.formatSize(size), nil
-}
-
-// GetWindowsTempSize returns the size of Windows temporary files
-func (r *CacheReporter) GetWindowsTempSize() (string, error) {
-	if runtime.GOOS != "windows" {
-		return "N/A", nil
-	}
-
-	tempDir := os.TempDir()
-	if _, err := os.Stat(tempDir); os.IsNotExist(err) {
-		return "Not found", nil
-	}
-	size, err := r.getDirSize(tempDir)
-	if err != nil {
-		return "Error", err
+// GetReport measures every source and returns the report, with rows in the
+// order the sources were added
+func (r *CacheReporter) GetReport() *Report {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	report := &Report{GeneratedAt: time.Now()}
+	volumes := make(map[string]bool)
+	for _, src := range r.sources {
+		row := Row{Name: src.name, Label: src.label, Part: src.part, Bytes: -1, Share: -1}
+		row.Status = r.measure(ctx, src, &row.Bytes)
+
+		if locator, ok := src.sizer.(Locator); ok && len(locator.CachePaths()) > 0 {
+			if volume, err := VolumeOf(locator.CachePaths()[0]); err == nil {
+				row.Volume = volume.Path
+				if row.Bytes >= 0 && volume.Total > 0 {
+					row.Share = float64(row.Bytes) / float64(volume.Total) * 100
+				}
+				if !volumes[volume.Path] {
+					volumes[volume.Path] = true
+					report.Volumes = append(report.Volumes, volume)
+				}
+			}
+		}
+		if row.Part == "" && row.Bytes > 0 {
+			report.Total += row.Bytes
+		}
+		report.Rows = append(report.Rows, row)
 	}
-	return r.formatSize(size), nil
+	return report
 }
 
-// GetWindowsChunkSize returns the size of Windows chunk files
-func (r *CacheReporter) GetWindowsChunkSize() (string, error) {
-	if runtime.GOOS != "windows" {
-		return "N/A", nil
+// measure sets bytes to the size of src if it can be measured, and returns
+// the size or state to display
+func (r *CacheReporter) measure(ctx context.Context, src source, bytes *int64) string {
+	// Prefer measuring in bytes so the result can be recorded, falling back
+	// to GetSize for its status text when there is nothing to measure
+	if m, ok := src.sizer.(Measurer); ok {
+		size, err := m.Measure(ctx)
+		switch {
+		case err != nil:
+			// Don't ask again, e.g. when a plugin timed out
+			return "Error"
+		case size > 0:
+			r.bytes[src.name] = size
+			*bytes = size
+			return r.formatSize(size)
+		case size == 0:
+			r.bytes[src.name] = 0
+			*bytes = 0
+		}
 	}
 
-	chunkDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "WER", "ReportQueue")
-	if _, err := os.Stat(chunkDir); os.IsNotExist(err) {
-		return "Not found", nil
-	}
-	size, err := r.getDirSize(chunkDir)
+	size, err := src.sizer.GetSize(ctx)
 	if err != nil {
-		return "Error", err
+		return "Error"
 	}
-	return r.formatSize(size), nil
+	return size
 }
 
-// GetCacheSizes returns a map of all cache sizes
-func (r *CacheReporter) GetCacheSizes() map[string]string {
-	sizes := make(map[string]string)
-
-	// NPM Cache
-	npmSize, err := r.GetNPMCacheSize()
-	if err != nil {
-		sizes["npm cache"] = "Error"
-	} else {
-		sizes["npm cache"] = npmSize
-	}
-
-	// Yarn Cache
-	yarnSize, err := r.GetYarnCacheSize()
-	if err != nil {
-		sizes["yarn cache"] = "Error"
-	} else {
-		sizes["yarn cache"] = yarnSize
-	}
-
-	// Docker Cache
-	dockerSize, err := r.GetDockerCacheSize()
-	if err != nil {
-		sizes["docker cache"] = "Error"
-	} else {
-		sizes["docker cache"] = dockerSize
-	}
-
-	// WinSxS Temp
-	winsxsSize, err := r.GetWinSxSTempSize()
-	if err != nil {
-		sizes["WinSxS temp"] = "Error"
-	} else {
-		sizes["WinSxS temp"] = winsxsSize
-	}
-
-	// Windows Temp
-	winTempSize, err := r.GetWindowsTempSize()
-	if err != nil {
-		sizes["Windows temp"] = "Error"
-	} else {
-		sizes["Windows temp"] = winTempSize
-	}
-
-	// Windows Chunks
-	winChunkSize, err := r.GetWindowsChunkSize()
-	if err != nil {
-		sizes["Windows chunks"] = "Error"
-	} else {
-		sizes["Windows chunks"] = winChunkSize
-	}
-
-	return sizes
+// GetBytes returns the sizes in bytes measured by the last GetReport call,
+// keyed by source name. Sources that could not be measured are absent.
+func (r *CacheReporter) GetBytes() map[string]int64 {
+	return r.bytes
 }
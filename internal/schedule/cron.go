@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// Cron schedules runs with an entry in the user's crontab
+type Cron struct{}
+
+// Markers around the entry, so it can be replaced and removed without
+// touching the rest of the crontab
+const (
+	cronBegin = "# BEGIN " + Name + " schedule, managed by clearance schedule"
+	cronEnd   = "# END " + Name + " schedule"
+)
+
+// Name implements Method
+func (m *Cron) Name() string { return "cron" }
+
+// Available reports whether crontab is installed
+func (m *Cron) Available(ctx context.Context) bool {
+	_, err := exec.LookPath("crontab")
+	return err == nil
+}
+
+// entry returns the crontab lines for s
+func (m *Cron) entry(s Schedule) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	var args []string
+	for _, arg := range s.Command {
+		args = append(args, cronQuote(arg))
+	}
+	line := "@" + s.Every + " " + strings.Join(args, " ")
+	if s.LogFile != "" {
+		line += " >> " + cronQuote(s.LogFile) + " 2>&1"
+	}
+	return cronBegin + "\n" + line + "\n" + cronEnd + "\n", nil
+}
+
+// cronQuote quotes s for the command field of a crontab line, where cron
+// turns an unescaped % into a newline even inside shell quotes
+func cronQuote(s string) string {
+	return strings.ReplaceAll(shellQuote(s), "%", `\%`)
+}
+
+// Files returns the crontab entry
+func (m *Cron) Files(s Schedule) (map[string]string, error) {
+	entry, err := m.entry(s)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"crontab": entry}, nil
+}
+
+// read returns the user's crontab without the clearance entry, and the
+// entry's schedule line if there is one
+func (m *Cron) read(ctx context.Context) (rest, line string, err error) {
+	out, err := run(ctx, "", "crontab", "-l")
+	if err != nil {
+		// crontab -l fails when the user has no crontab yet
+		if strings.Contains(strings.ToLower(out), "no crontab") {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	var kept []string
+	inside := false
+	for _, l := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		switch {
+		case l == cronBegin:
+			inside = true
+		case l == cronEnd:
+			inside = false
+		case inside:
+			line = l
+		default:
+			kept = append(kept, l)
+		}
+	}
+	rest = strings.Join(kept, "\n")
+	if rest != "" {
+		rest += "\n"
+	}
+	return rest, line, nil
+}
+
+// Install replaces the clearance entry in the crontab
+func (m *Cron) Install(ctx context.Context, s Schedule) error {
+	entry, err := m.entry(s)
+	if err != nil {
+		return err
+	}
+	rest, _, err := m.read(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = run(ctx, rest+entry, "crontab", "-")
+	return err
+}
+
+// Remove deletes the clearance entry from the crontab
+func (m *Cron) Remove(ctx context.Context) error {
+	rest, line, err := m.read(ctx)
+	if err != nil {
+		return err
+	}
+	if line == "" {
+		return ErrNotInstalled
+	}
+	if rest == "" {
+		_, err = run(ctx, "", "crontab", "-r")
+		return err
+	}
+	_, err = run(ctx, rest, "crontab", "-")
+	return err
+}
+
+// Status reads the clearance entry from the crontab
+func (m *Cron) Status(ctx context.Context) (Status, error) {
+	status := Status{Method: m.Name()}
+	_, line, err := m.read(ctx)
+	if err != nil || line == "" {
+		return status, err
+	}
+	status.Installed = true
+	every, command, _ := strings.Cut(line, " ")
+	status.Every = strings.TrimPrefix(every, "@")
+	status.Command = command
+	return status, nil
+}
@@ -0,0 +1,38 @@
+package schedule
+
+import "testing"
+
+func TestCronEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     string
+	}{
+		{
+			name:     "plain",
+			schedule: Schedule{Every: "daily", Command: []string{"/usr/bin/clearance", "clean", "all"}},
+			want:     "@daily /usr/bin/clearance clean all",
+		},
+		{
+			name:     "quoted",
+			schedule: Schedule{Every: "weekly", Command: []string{"/opt/my tools/clearance", "it's"}},
+			want:     `@weekly '/opt/my tools/clearance' 'it'\''s'`,
+		},
+		{
+			name:     "percent signs",
+			schedule: Schedule{Every: "daily", Command: []string{"/home/a/100%/clearance", "--config", "/tmp/%d.yaml"}, LogFile: "/var/log/%u.log"},
+			want:     `@daily '/home/a/100\%/clearance' --config '/tmp/\%d.yaml' >> '/var/log/\%u.log' 2>&1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&Cron{}).entry(tt.schedule)
+			if err != nil {
+				t.Fatalf("entry: %v", err)
+			}
+			if want := cronBegin + "\n" + tt.want + "\n" + cronEnd + "\n"; got != want {
+				t.Errorf("entry =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
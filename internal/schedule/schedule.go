@@ -0,0 +1,130 @@
+// Package schedule installs periodic clearance runs with the system
+// scheduler: a systemd user timer, a crontab entry or a Windows scheduled
+// task
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Supported intervals
+const (
+	Hourly  = "hourly"
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// Intervals lists the supported intervals
+var Intervals = []string{Hourly, Daily, Weekly, Monthly}
+
+// Name identifies the installed units, entries and tasks
+const Name = "clearance"
+
+// ErrNotInstalled is returned by Remove when nothing is scheduled
+var ErrNotInstalled = errors.New("no clearance schedule is installed")
+
+// Schedule is a periodic clearance run
+type Schedule struct {
+	// Every is one of Intervals
+	Every string
+	// Command is the executable and arguments to run
+	Command []string
+	// LogFile receives the output where the scheduler does not keep it,
+	// i.e. for cron
+	LogFile string
+}
+
+// Validate checks the interval and command
+func (s Schedule) Validate() error {
+	for _, every := range Intervals {
+		if s.Every == every {
+			if len(s.Command) == 0 {
+				return errors.New("schedule has no command")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported interval %q, expected one of %s", s.Every, strings.Join(Intervals, ", "))
+}
+
+// Status describes the installed schedule
+type Status struct {
+	Method    string
+	Installed bool
+	// Every and Command are as installed, if they can be read back
+	Every   string
+	Command string
+	// Details are scheduler specific lines, e.g. the next run
+	Details []string
+}
+
+// Method is a way of scheduling runs
+type Method interface {
+	// Name is how the method is selected, e.g. "systemd"
+	Name() string
+	// Available reports whether the method can be used here
+	Available(ctx context.Context) bool
+	// Files returns the files or entries Install would write, keyed by a
+	// description of where they go
+	Files(s Schedule) (map[string]string, error)
+	// Install replaces any installed schedule with s
+	Install(ctx context.Context, s Schedule) error
+	// Remove removes the installed schedule
+	Remove(ctx context.Context) error
+	// Status reports what is installed
+	Status(ctx context.Context) (Status, error)
+}
+
+// Methods returns every method supported on this platform, preferred first
+func Methods() []Method {
+	if runtime.GOOS == "windows" {
+		return []Method{&TaskScheduler{}}
+	}
+	return []Method{&Systemd{}, &Cron{}}
+}
+
+// Lookup returns the method with the given name, or the first available
+// one for "auto"
+func Lookup(ctx context.Context, name string) (Method, error) {
+	var names []string
+	for _, m := range Methods() {
+		names = append(names, m.Name())
+		if name == m.Name() || (name == "auto" && m.Available(ctx)) {
+			return m, nil
+		}
+	}
+	if name == "auto" {
+		return nil, fmt.Errorf("no scheduler found, tried %s", strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("unknown method %q, expected auto or one of %s", name, strings.Join(names, ", "))
+}
+
+// run runs a scheduler command, including its output in the error
+func run(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return string(out), fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return string(out), fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
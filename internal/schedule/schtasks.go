@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// TaskScheduler schedules runs with a Windows scheduled task
+type TaskScheduler struct{}
+
+// Name implements Method
+func (m *TaskScheduler) Name() string { return "schtasks" }
+
+// Available reports whether schtasks is installed
+func (m *TaskScheduler) Available(ctx context.Context) bool {
+	_, err := exec.LookPath("schtasks")
+	return err == nil
+}
+
+// taskRun returns the command line the task runs. It goes through cmd so
+// the output can be appended to the log file.
+func (m *TaskScheduler) taskRun(s Schedule) string {
+	var args []string
+	for _, arg := range s.Command {
+		args = append(args, windowsQuote(arg))
+	}
+	command := strings.Join(args, " ")
+	if s.LogFile == "" {
+		return command
+	}
+	// cmd /c strips the outer quotes when the command starts with one
+	return `cmd /c "` + command + ` >> ` + windowsQuote(s.LogFile) + ` 2>&1"`
+}
+
+// createArgs returns the schtasks arguments that create the task
+func (m *TaskScheduler) createArgs(s Schedule) ([]string, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return []string{"/Create", "/F", "/TN", Name, "/SC", strings.ToUpper(s.Every), "/TR", m.taskRun(s)}, nil
+}
+
+// Files returns the schtasks command that creates the task
+func (m *TaskScheduler) Files(s Schedule) (map[string]string, error) {
+	args, err := m.createArgs(s)
+	if err != nil {
+		return nil, err
+	}
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, windowsQuote(arg))
+	}
+	return map[string]string{"scheduled task " + Name: "schtasks " + strings.Join(quoted, " ") + "\n"}, nil
+}
+
+// Install creates or replaces the task
+func (m *TaskScheduler) Install(ctx context.Context, s Schedule) error {
+	args, err := m.createArgs(s)
+	if err != nil {
+		return err
+	}
+	_, err = run(ctx, "", "schtasks", args...)
+	return err
+}
+
+// Remove deletes the task
+func (m *TaskScheduler) Remove(ctx context.Context) error {
+	if _, err := run(ctx, "", "schtasks", "/Query", "/TN", Name); err != nil {
+		return ErrNotInstalled
+	}
+	_, err := run(ctx, "", "schtasks", "/Delete", "/F", "/TN", Name)
+	return err
+}
+
+// Status queries the task
+func (m *TaskScheduler) Status(ctx context.Context) (Status, error) {
+	status := Status{Method: m.Name()}
+	out, err := run(ctx, "", "schtasks", "/Query", "/TN", Name, "/FO", "LIST", "/V")
+	if err != nil {
+		// Querying a task that does not exist fails
+		return status, nil
+	}
+	status.Installed = true
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "Schedule Type":
+			status.Every = value
+		case "Task To Run":
+			status.Command = value
+		case "Status", "Next Run Time", "Last Run Time", "Last Result":
+			status.Details = append(status.Details, key+": "+value)
+		}
+	}
+	return status, nil
+}
+
+// windowsQuote quotes s as a Windows command line argument
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,172 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Systemd schedules runs with a systemd user timer and service
+type Systemd struct{}
+
+// Name implements Method
+func (m *Systemd) Name() string { return "systemd" }
+
+// Available reports whether a systemd user manager is running
+func (m *Systemd) Available(ctx context.Context) bool {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	_, err := run(ctx, "", "systemctl", "--user", "show-environment")
+	return err == nil
+}
+
+// unitDir returns where user units are installed, ~/.config/systemd/user
+func (m *Systemd) unitDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// Files returns the service and timer units
+func (m *Systemd) Files(s Schedule) (map[string]string, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	dir, err := m.unitDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	for _, arg := range s.Command {
+		args = append(args, systemdQuote(arg))
+	}
+	service := fmt.Sprintf(`[Unit]
+Description=Clearance cache cleanup
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, strings.Join(args, " "))
+	timer := fmt.Sprintf(`[Unit]
+Description=Run clearance %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+RandomizedDelaySec=15min
+
+[Install]
+WantedBy=timers.target
+`, s.Every, s.Every)
+
+	return map[string]string{
+		filepath.Join(dir, Name+".service"): service,
+		filepath.Join(dir, Name+".timer"):   timer,
+	}, nil
+}
+
+// Install writes the units and enables the timer
+func (m *Systemd) Install(ctx context.Context, s Schedule) error {
+	files, err := m.Files(s)
+	if err != nil {
+		return err
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	if _, err := run(ctx, "", "systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	_, err = run(ctx, "", "systemctl", "--user", "enable", "--now", Name+".timer")
+	return err
+}
+
+// Remove disables the timer and deletes the units
+func (m *Systemd) Remove(ctx context.Context) error {
+	dir, err := m.unitDir()
+	if err != nil {
+		return err
+	}
+	timer := filepath.Join(dir, Name+".timer")
+	if _, err := os.Stat(timer); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+
+	// The timer may already be stopped or the manager gone; remove the units anyway
+	_, _ = run(ctx, "", "systemctl", "--user", "disable", "--now", Name+".timer")
+	for _, path := range []string{timer, filepath.Join(dir, Name+".service")} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	_, err = run(ctx, "", "systemctl", "--user", "daemon-reload")
+	return err
+}
+
+// Status reads the installed units and asks systemd about the timer
+func (m *Systemd) Status(ctx context.Context) (Status, error) {
+	status := Status{Method: m.Name()}
+	dir, err := m.unitDir()
+	if err != nil {
+		return status, err
+	}
+	timer, err := os.ReadFile(filepath.Join(dir, Name+".timer"))
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	status.Installed = true
+	status.Every = unitValue(string(timer), "OnCalendar")
+	if service, err := os.ReadFile(filepath.Join(dir, Name+".service")); err == nil {
+		status.Command = unitValue(string(service), "ExecStart")
+	}
+
+	out, err := run(ctx, "", "systemctl", "--user", "show", Name+".timer",
+		"-p", "ActiveState", "-p", "LastTriggerUSec", "-p", "NextElapseUSecRealtime")
+	if err != nil {
+		status.Details = append(status.Details, "systemd: "+err.Error())
+		return status, nil
+	}
+	labels := map[string]string{"ActiveState": "State", "LastTriggerUSec": "Last run", "NextElapseUSecRealtime": "Next run"}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if label, known := labels[key]; ok && known && value != "" {
+			status.Details = append(status.Details, label+": "+value)
+		}
+	}
+	return status, nil
+}
+
+// unitValue returns the value of the first key= line in a unit file
+func unitValue(unit, key string) string {
+	for _, line := range strings.Split(unit, "\n") {
+		if value, ok := strings.CutPrefix(line, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// systemdQuote quotes an ExecStart argument, escaping specifiers and
+// variable expansion
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
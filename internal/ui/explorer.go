@@ -0,0 +1,346 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+// ExploreEntry is a file or directory shown by the explorer
+type ExploreEntry struct {
+	Name     string
+	Path     string
+	Size     int64
+	Modified time.Time
+	Dir      bool
+	// Children are shown in order, largest first
+	Children []*ExploreEntry
+	// Error is shown next to entries that could not be read completely
+	Error string
+
+	parent  *ExploreEntry
+	deleted bool
+}
+
+// ExploreHooks connects the explorer to the file system
+type ExploreHooks struct {
+	// Delete removes the entry at path
+	Delete func(path string) error
+	// FormatSize formats a size in bytes for display
+	FormatSize func(size int64) string
+}
+
+type explorer struct {
+	screen  *screen
+	opts    Options
+	hooks   ExploreHooks
+	title   string
+	dir     *ExploreEntry
+	cursor  int
+	top     int
+	marked  map[*ExploreEntry]bool
+	pending []*ExploreEntry
+	message string
+	freed   int64
+}
+
+// RunExplorer lets the user browse root, open directories, mark entries and
+// delete them through hooks.Delete. It returns the space freed.
+func RunExplorer(title string, root *ExploreEntry, hooks ExploreHooks) (int64, error) {
+	linkParents(root)
+	screen, err := openScreen()
+	if err != nil {
+		return 0, err
+	}
+	defer screen.close()
+
+	e := &explorer{
+		screen: screen,
+		opts:   options,
+		hooks:  hooks,
+		title:  title,
+		dir:    root,
+		marked: make(map[*ExploreEntry]bool),
+	}
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	for {
+		e.render()
+		key, ok := <-keys
+		if !ok || e.handle(key) {
+			return e.freed, nil
+		}
+	}
+}
+
+func linkParents(entry *ExploreEntry) {
+	for _, child := range entry.Children {
+		child.parent = entry
+		linkParents(child)
+	}
+}
+
+// handle acts on a key and reports whether the explorer should close
+func (e *explorer) handle(key string) bool {
+	if e.pending != nil {
+		if key == "y" || key == "Y" {
+			e.delete(e.pending)
+		} else {
+			e.message = "Nothing deleted"
+		}
+		e.pending = nil
+		return false
+	}
+
+	e.message = ""
+	last := len(e.dir.Children) - 1
+	switch key {
+	case "q", "esc", "ctrl+c":
+		return true
+	case "up", "k":
+		e.cursor = max(e.cursor-1, 0)
+	case "down", "j":
+		e.cursor = max(min(e.cursor+1, last), 0)
+	case "home", "g":
+		e.cursor = 0
+	case "end", "G":
+		e.cursor = max(last, 0)
+	case "pgup":
+		e.cursor = max(e.cursor-10, 0)
+	case "pgdn":
+		e.cursor = max(min(e.cursor+10, last), 0)
+	case "enter", "right", "l":
+		if current := e.current(); current != nil && current.Dir {
+			e.dir, e.cursor, e.top = current, 0, 0
+		}
+	case "left", "h", "backspace":
+		if e.dir.parent != nil {
+			e.leave()
+		}
+	case "space":
+		if current := e.current(); current != nil {
+			e.marked[current] = !e.marked[current]
+			if !e.marked[current] {
+				delete(e.marked, current)
+			}
+			e.cursor = min(e.cursor+1, last)
+		}
+	case "d":
+		var targets []*ExploreEntry
+		for entry := range e.marked {
+			targets = append(targets, entry)
+		}
+		if len(targets) == 0 {
+			if current := e.current(); current != nil {
+				targets = append(targets, current)
+			}
+		}
+		if len(targets) > 0 {
+			e.pending = targets
+		}
+	}
+	return false
+}
+
+// current returns the entry under the cursor, if any
+func (e *explorer) current() *ExploreEntry {
+	if e.cursor < 0 || e.cursor >= len(e.dir.Children) {
+		return nil
+	}
+	return e.dir.Children[e.cursor]
+}
+
+// leave moves to the parent directory with the cursor on the one left
+func (e *explorer) leave() {
+	child := e.dir
+	e.dir, e.cursor, e.top = child.parent, 0, 0
+	for i, entry := range e.dir.Children {
+		if entry == child {
+			e.cursor = i
+		}
+	}
+}
+
+// delete removes the targets, skipping those inside another target
+func (e *explorer) delete(targets []*ExploreEntry) {
+	var failed []string
+	deleted := 0
+	for _, entry := range targets {
+		if hasAncestor(entry, targets) {
+			continue
+		}
+		if err := e.hooks.Delete(entry.Path); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		deleted++
+		e.freed += entry.Size
+		e.detach(entry)
+	}
+	for entry := range e.marked {
+		if !e.exists(entry) {
+			delete(e.marked, entry)
+		}
+	}
+
+	// Step out of directories that no longer exist
+	for !e.exists(e.dir) {
+		e.leave()
+	}
+	e.cursor = max(min(e.cursor, len(e.dir.Children)-1), 0)
+
+	e.message = fmt.Sprintf("Deleted %d of %d", deleted, deleted+len(failed))
+	if len(failed) > 0 {
+		e.message += ": " + strings.Join(failed, "; ")
+	}
+}
+
+// detach removes entry from the tree and its size from its ancestors
+func (e *explorer) detach(entry *ExploreEntry) {
+	parent := entry.parent
+	for i, child := range parent.Children {
+		if child == entry {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.Size -= entry.Size
+	}
+	entry.deleted = true
+}
+
+// exists reports whether entry is still attached to the tree
+func (e *explorer) exists(entry *ExploreEntry) bool {
+	for p := entry; p != nil; p = p.parent {
+		if p.deleted {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAncestor reports whether one of targets contains entry
+func hasAncestor(entry *ExploreEntry, targets []*ExploreEntry) bool {
+	for p := entry.parent; p != nil; p = p.parent {
+		for _, target := range targets {
+			if p == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// render redraws the whole screen
+func (e *explorer) render() {
+	width, height := e.screen.size()
+	format := e.hooks.FormatSize
+
+	path := e.dir.Path
+	if path == "" {
+		path = e.dir.Name
+	}
+	lines := []string{
+		color.Blue.Sprint(truncate(e.title, width)),
+		truncate(fmt.Sprintf("%s  %s", path, format(e.dir.Size)), width),
+		"",
+	}
+
+	rows := max(height-len(lines)-4, 1)
+	if e.cursor < e.top {
+		e.top = e.cursor
+	}
+	if e.cursor >= e.top+rows {
+		e.top = e.cursor - rows + 1
+	}
+
+	var largest int64
+	for _, entry := range e.dir.Children {
+		largest = max(largest, entry.Size)
+	}
+	if len(e.dir.Children) == 0 {
+		lines = append(lines, color.Gray.Sprint("  (empty)"))
+	}
+	now := time.Now()
+	for i := e.top; i < len(e.dir.Children) && i < e.top+rows; i++ {
+		entry := e.dir.Children[i]
+		mark := " "
+		if e.marked[entry] {
+			mark = "*"
+		}
+		name := entry.Name
+		if entry.Dir {
+			name += string(os.PathSeparator)
+		}
+		line := fmt.Sprintf("%s %10s %s %4s  %s", mark, format(entry.Size), e.bar(entry.Size, largest), formatAge(now.Sub(entry.Modified)), name)
+		line = truncate(line, width)
+		switch {
+		case i == e.cursor:
+			line = color.Cyan.Sprint(line)
+		case e.marked[entry]:
+			line = color.Yellow.Sprint(line)
+		}
+		if entry.Error != "" {
+			line += color.Red.Sprint("  " + entry.Error)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	if len(e.marked) > 0 {
+		var size int64
+		for entry := range e.marked {
+			size += entry.Size
+		}
+		lines = append(lines, fmt.Sprintf("%d marked (%s)", len(e.marked), format(size)))
+	}
+	switch {
+	case e.pending != nil:
+		var size int64
+		for _, entry := range e.pending {
+			size += entry.Size
+		}
+		lines = append(lines, color.Red.Sprintf("Delete %d entries (%s)? [y/N]", len(e.pending), format(size)))
+	case e.message != "":
+		lines = append(lines, color.Yellow.Sprint(truncate(e.message, width)))
+	}
+	lines = append(lines, color.Gray.Sprint(truncate("up/down move  enter open  left back  space mark  d delete  q quit", width)))
+	e.screen.draw(lines)
+}
+
+// bar renders size relative to the largest entry in the directory
+func (e *explorer) bar(size, largest int64) string {
+	const width = 10
+	full, empty := "█", " "
+	if !e.opts.Emoji {
+		full = "#"
+	}
+	n := 0
+	if largest > 0 {
+		n = int(size * width / largest)
+	}
+	return "[" + strings.Repeat(full, n) + strings.Repeat(empty, width-n) + "]"
+}
+
+// formatAge formats how long ago something was modified, e.g. "3d"
+func formatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < day:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 30*day:
+		return fmt.Sprintf("%dd", int(d/day))
+	case d < 365*day:
+		return fmt.Sprintf("%dmo", int(d/(30*day)))
+	default:
+		return fmt.Sprintf("%dy", int(d/(365*day)))
+	}
+}
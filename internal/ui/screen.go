@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// screen is the terminal in raw mode showing the alternate screen, used by
+// the full-screen views
+type screen struct {
+	out   *os.File
+	fd    int
+	state *term.State
+}
+
+// openScreen switches the terminal to raw mode and the alternate screen
+func openScreen() (*screen, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	s := &screen{out: os.Stdout, fd: fd, state: state}
+	fmt.Fprint(s.out, "\x1b[?1049h\x1b[?25l")
+	return s, nil
+}
+
+// close restores the terminal to how openScreen found it
+func (s *screen) close() {
+	fmt.Fprint(s.out, "\x1b[?25h\x1b[?1049l")
+	_ = term.Restore(s.fd, s.state)
+}
+
+// size returns the terminal width and height, or 80x24 if unknown
+func (s *screen) size() (int, int) {
+	width, height, err := term.GetSize(int(s.out.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// draw replaces the screen contents with lines
+func (s *screen) draw(lines []string) {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K\r\n")
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprint(s.out, b.String())
+}
+
+// readKeys sends the keys read from f until it cannot be read
+func readKeys(f *os.File, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+var escapeKeys = map[string]string{
+	"[A": "up", "OA": "up",
+	"[B": "down", "OB": "down",
+	"[C": "right", "OC": "right",
+	"[D": "left", "OD": "left",
+	"[H": "home", "OH": "home", "[1~": "home",
+	"[F": "end", "OF": "end", "[4~": "end",
+	"[5~": "pgup", "[6~": "pgdn",
+}
+
+// parseKeys splits raw terminal input into key names
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) > 1:
+			seq, rest := b[1:], []byte(nil)
+			// Sequences end at the first letter or ~ after the introducer
+			for j := 1; j < len(seq); j++ {
+				if c := seq[j]; c == '~' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
+					seq, rest = seq[:j+1], seq[j+1:]
+					break
+				}
+			}
+			if key, ok := escapeKeys[string(seq)]; ok {
+				keys = append(keys, key)
+			}
+			b = rest
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, "esc")
+		case b[0] == 0x03:
+			keys = append(keys, "ctrl+c")
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, "enter")
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, "backspace")
+		case b[0] == ' ':
+			keys = append(keys, "space")
+		default:
+			keys = append(keys, string(b[0]))
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/gookit/color"
+	"golang.org/x/term"
+)
+
+// Options controls how output is rendered
+type Options struct {
+	// Color enables ANSI colors
+	Color bool
+	// Emoji enables emoji and other pictographs
+	Emoji bool
+}
+
+var options Options
+
+func init() {
+	Configure(DetectOptions())
+}
+
+// DetectOptions returns the options suited to the current process. Colors
+// and emoji are only used when stdout is a terminal, and colors are turned
+// off by NO_COLOR or TERM=dumb.
+func DetectOptions() Options {
+	tty := StdoutIsTerminal()
+	return Options{
+		Color: tty && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+		Emoji: tty,
+	}
+}
+
+// Configure sets the options used by every UI
+func Configure(o Options) {
+	options = o
+	color.Enable = o.Color
+}
+
+// StdinIsTerminal reports whether standard input is an interactive terminal
+func StdinIsTerminal() bool {
+	return isTerminal(os.Stdin)
+}
+
+// StdoutIsTerminal reports whether standard output is an interactive terminal
+func StdoutIsTerminal() bool {
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal asks the terminal driver rather than checking for a character
+// device, which /dev/null is too
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,528 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+	"golang.org/x/term"
+)
+
+// TUIItem is a cleaner shown in the full-screen interface
+type TUIItem struct {
+	Key         string
+	Icon        string
+	Description string
+	// InAll marks the items selected by the "select all" key
+	InAll bool
+}
+
+// ItemState is where an item is in a cleanup run
+type ItemState int
+
+const (
+	ItemIdle ItemState = iota
+	ItemWaiting
+	ItemRunning
+	ItemDone
+	ItemSkipped
+	ItemFailed
+)
+
+// TUIHooks connects the full-screen interface to the cleaners. The hooks are
+// called from background goroutines.
+type TUIHooks struct {
+	// Measure returns the size of item i for display
+	Measure func(ctx context.Context, i int) string
+	// Clean cleans the selected items in order, reporting their progress
+	// through t, and returns lines summarizing the run
+	Clean func(ctx context.Context, selected []int, t *TUI) []string
+}
+
+type tuiPhase int
+
+const (
+	phaseSelecting tuiPhase = iota
+	phaseCleaning
+	phaseFinished
+)
+
+type tuiItem struct {
+	TUIItem
+	selected bool
+	size     string
+	state    ItemState
+	progress float64
+	detail   string
+}
+
+// TUI is a full-screen interface for choosing and running cleaners
+type TUI struct {
+	mu       sync.Mutex
+	opts     Options
+	screen   *screen
+	title    string
+	items    []tuiItem
+	cursor   int
+	top      int
+	phase    tuiPhase
+	running  int
+	message  string
+	summary  []string
+	log      []string
+	frame    int
+	changed  chan struct{}
+	captured chan struct{}
+}
+
+// TUISupported reports whether the terminal can show the full-screen
+// interface
+func TUISupported() bool {
+	if !StdinIsTerminal() || !StdoutIsTerminal() || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	return err == nil && width >= 60 && height >= 12
+}
+
+// RunTUI shows the full-screen interface until the user quits or a cleanup
+// run finishes and is dismissed. Output printed while it is shown, such as
+// the cleaners' progress messages, is repeated on the normal screen once it
+// closes. An error is only returned if the terminal could not be set up.
+func RunTUI(ctx context.Context, title string, items []TUIItem, hooks TUIHooks) error {
+	t := &TUI{
+		opts:     options,
+		title:    title,
+		running:  -1,
+		changed:  make(chan struct{}, 1),
+		captured: make(chan struct{}),
+	}
+	for _, item := range items {
+		t.items = append(t.items, tuiItem{TUIItem: item})
+	}
+
+	restore, err := t.enter()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		restore()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for _, line := range t.log {
+			fmt.Println(line)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go t.measure(ctx, hooks.Measure)
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	// q cancels a run without closing the interface
+	cleanCtx, cancelClean := context.WithCancel(ctx)
+	defer cancelClean()
+
+	var cleaned chan []string
+	for {
+		t.render()
+		select {
+		case <-ctx.Done():
+			if cleaned == nil {
+				return nil
+			}
+			// Wait for the cleaner to notice so the run is recorded
+			<-cleaned
+			return nil
+		case <-ticker.C:
+			t.mu.Lock()
+			t.frame++
+			t.mu.Unlock()
+		case <-t.changed:
+		case summary := <-cleaned:
+			cleaned = nil
+			t.mu.Lock()
+			t.phase = phaseFinished
+			t.running = -1
+			t.summary = summary
+			t.mu.Unlock()
+			if keys == nil {
+				return nil
+			}
+		case key, ok := <-keys:
+			if !ok {
+				// Input is gone: finish a run in progress, then close
+				if cleaned == nil {
+					return nil
+				}
+				keys = nil
+				continue
+			}
+			t.mu.Lock()
+			phase := t.phase
+			t.mu.Unlock()
+			switch phase {
+			case phaseSelecting:
+				switch key {
+				case "q", "esc", "ctrl+c":
+					return nil
+				case "enter":
+					selected := t.start()
+					if len(selected) == 0 {
+						break
+					}
+					cleaned = make(chan []string, 1)
+					go func() {
+						cleaned <- hooks.Clean(cleanCtx, selected, t)
+					}()
+				default:
+					t.navigate(key)
+				}
+			case phaseCleaning:
+				if key == "q" || key == "esc" || key == "ctrl+c" {
+					cancelClean()
+					t.setMessage("Cancelling...")
+				}
+			case phaseFinished:
+				return nil
+			}
+		}
+	}
+}
+
+// enter opens the screen and captures everything else printed until the
+// returned function is called
+func (t *TUI) enter() (func(), error) {
+	screen, err := openScreen()
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		screen.close()
+		return nil, err
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	t.screen = screen
+	os.Stdout, os.Stderr = w, w
+	color.SetOutput(w)
+	go t.capture(r)
+
+	return func() {
+		screen.close()
+		os.Stdout, os.Stderr = stdout, stderr
+		color.SetOutput(stdout)
+		_ = w.Close()
+		// Processes started by cleaners may keep the pipe open after they
+		// were cancelled, so only wait briefly for the rest of their output
+		select {
+		case <-t.captured:
+		case <-time.After(time.Second):
+		}
+		_ = r.Close()
+	}, nil
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// capture collects the lines printed while the interface is shown and
+// shows the latest one under the running item
+func (t *TUI) capture(r *os.File) {
+	defer close(t.captured)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := ansiEscape.ReplaceAllString(scanner.Text(), "")
+		t.mu.Lock()
+		t.log = append(t.log, line)
+		if t.running >= 0 && strings.TrimSpace(line) != "" {
+			t.items[t.running].detail = strings.TrimSpace(line)
+		}
+		t.mu.Unlock()
+		t.notify()
+	}
+}
+
+// measure fills in the item sizes, a few at a time
+func (t *TUI) measure(ctx context.Context, measure func(ctx context.Context, i int) string) {
+	const workers = 4
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				size := measure(ctx, i)
+				t.mu.Lock()
+				t.items[i].size = size
+				t.mu.Unlock()
+				t.notify()
+			}
+		}()
+	}
+	for i := range t.items {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+}
+
+func (t *TUI) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (t *TUI) setMessage(msg string) {
+	t.mu.Lock()
+	t.message = msg
+	t.mu.Unlock()
+}
+
+// navigate handles the keys that move the cursor and change the selection
+func (t *TUI) navigate(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.message = ""
+	switch key {
+	case "up", "k":
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case "down", "j":
+		if t.cursor < len(t.items)-1 {
+			t.cursor++
+		}
+	case "home", "g":
+		t.cursor = 0
+	case "end", "G":
+		t.cursor = len(t.items) - 1
+	case "pgup":
+		t.cursor = max(t.cursor-10, 0)
+	case "pgdn":
+		t.cursor = min(t.cursor+10, len(t.items)-1)
+	case "space", "x":
+		t.items[t.cursor].selected = !t.items[t.cursor].selected
+	case "a":
+		// Clear the selection if there is one, otherwise select what "all" cleans
+		selected := false
+		for _, item := range t.items {
+			selected = selected || item.selected
+		}
+		for i := range t.items {
+			t.items[i].selected = !selected && t.items[i].InAll
+		}
+	}
+}
+
+// start moves to the cleaning phase and returns the selected items
+func (t *TUI) start() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var selected []int
+	for i := range t.items {
+		if t.items[i].selected {
+			selected = append(selected, i)
+			t.items[i].state = ItemWaiting
+		}
+	}
+	if len(selected) == 0 {
+		t.message = "Select at least one cleaner with space"
+		return nil
+	}
+	t.phase = phaseCleaning
+	t.message = ""
+	return selected
+}
+
+// Start marks item i as running
+func (t *TUI) Start(i int) {
+	t.mu.Lock()
+	t.items[i].state = ItemRunning
+	t.items[i].progress = -1
+	t.items[i].detail = ""
+	t.running = i
+	t.mu.Unlock()
+	t.notify()
+}
+
+// Progress reports how much of item i has been cleaned, between 0 and 1, or
+// -1 if it is not known
+func (t *TUI) Progress(i int, fraction float64) {
+	t.mu.Lock()
+	t.items[i].progress = fraction
+	t.mu.Unlock()
+	t.notify()
+}
+
+// Finish marks item i as done, skipped or failed, with a short explanation
+func (t *TUI) Finish(i int, state ItemState, detail string) {
+	t.mu.Lock()
+	t.items[i].state = state
+	t.items[i].detail = detail
+	if t.running == i {
+		t.running = -1
+	}
+	t.mu.Unlock()
+	t.notify()
+}
+
+// render redraws the whole screen
+func (t *TUI) render() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	width, height := t.screen.size()
+
+	var lines []string
+	lines = append(lines, color.Blue.Sprint(t.title), "")
+
+	// Keep the cursor, or the running item, on screen, leaving room for the
+	// header and footer
+	rows := max(height-len(lines)-5, 1)
+	focus := t.cursor
+	if t.running >= 0 {
+		focus = t.running
+	}
+	if focus < t.top {
+		t.top = focus
+	}
+	if focus >= t.top+rows {
+		t.top = focus - rows + 1
+	}
+	nameWidth := 0
+	for _, item := range t.items {
+		nameWidth = max(nameWidth, len([]rune(item.Description)))
+	}
+	nameWidth = min(nameWidth, max(width/2-8, 10))
+
+	for i := t.top; i < len(t.items) && i < t.top+rows; i++ {
+		lines = append(lines, t.row(i, nameWidth))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, t.footer(width)...)
+
+	t.screen.draw(lines)
+}
+
+// row renders item i
+func (t *TUI) row(i, nameWidth int) string {
+	item := t.items[i]
+	name := truncate(item.Description, nameWidth)
+	name += strings.Repeat(" ", nameWidth-len([]rune(name)))
+	icon := ""
+	if t.opts.Emoji && item.Icon != "" {
+		icon = item.Icon + " "
+	}
+
+	if t.phase == phaseSelecting {
+		pointer, box := "  ", "[ ]"
+		if i == t.cursor {
+			pointer = "> "
+		}
+		if item.selected {
+			box = "[x]"
+		}
+		size := item.size
+		if size == "" {
+			size = t.spinner()
+		}
+		line := fmt.Sprintf("%s%s %s%s  %s", pointer, box, icon, name, size)
+		if i == t.cursor {
+			return color.Cyan.Sprint(line)
+		}
+		return line
+	}
+
+	if item.state == ItemIdle {
+		return color.Gray.Sprintf("      %s%s", icon, name)
+	}
+	line := fmt.Sprintf("      %s%s  ", icon, name)
+	switch item.state {
+	case ItemWaiting:
+		return line + color.Gray.Sprint("waiting")
+	case ItemRunning:
+		return line + color.Yellow.Sprint(t.bar(item.progress))
+	case ItemDone:
+		return line + color.Green.Sprint(item.detail)
+	case ItemSkipped:
+		return line + color.Yellow.Sprint(item.detail)
+	default:
+		return line + color.Red.Sprint(item.detail)
+	}
+}
+
+// footer renders the status lines and key help for the current phase
+func (t *TUI) footer(width int) []string {
+	var lines []string
+	switch t.phase {
+	case phaseSelecting:
+		selected := 0
+		for _, item := range t.items {
+			if item.selected {
+				selected++
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%d selected", selected))
+		if t.message != "" {
+			lines = append(lines, color.Yellow.Sprint(t.message))
+		}
+		lines = append(lines, color.Gray.Sprint(truncate("up/down move  space select  a all  enter clean  q quit", width)))
+	case phaseCleaning:
+		if t.running >= 0 && t.items[t.running].detail != "" {
+			lines = append(lines, truncate(t.items[t.running].detail, width))
+		}
+		if t.message != "" {
+			lines = append(lines, color.Yellow.Sprint(t.message))
+		}
+		lines = append(lines, color.Gray.Sprint("q cancel"))
+	case phaseFinished:
+		for _, line := range t.summary {
+			lines = append(lines, color.Green.Sprint(truncate(line, width)))
+		}
+		lines = append(lines, color.Gray.Sprint("Press any key to exit"))
+	}
+	return lines
+}
+
+// bar renders a progress bar, or a bouncing block when progress is unknown
+func (t *TUI) bar(fraction float64) string {
+	const width = 20
+	full, empty := "█", "░"
+	if !t.opts.Emoji {
+		full, empty = "#", "-"
+	}
+	if fraction < 0 {
+		const block = 4
+		span := width - block
+		pos := t.frame % (2 * span)
+		if pos > span {
+			pos = 2*span - pos
+		}
+		return "[" + strings.Repeat(empty, pos) + strings.Repeat(full, block) + strings.Repeat(empty, span-pos) + "]"
+	}
+	fraction = min(max(fraction, 0), 1)
+	n := int(fraction * width)
+	return fmt.Sprintf("[%s%s] %3d%%", strings.Repeat(full, n), strings.Repeat(empty, width-n), int(fraction*100))
+}
+
+func (t *TUI) spinner() string {
+	frames := []string{"measuring.  ", "measuring.. ", "measuring..."}
+	return color.Gray.Sprint(frames[t.frame/3%len(frames)])
+}
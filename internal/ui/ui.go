@@ -14,17 +14,39 @@ import (
 // UI handles all user interface interactions
 type UI struct {
 	reader *bufio.Reader
+	opts   Options
 }
 
-// NewUI creates a new UI instance
+// NewUI creates a new UI instance using the options set by Configure
 func NewUI() *UI {
 	return &UI{
 		reader: bufio.NewReader(os.Stdin),
+		opts:   options,
 	}
 }
 
-// ClearScreen clears the terminal screen
+// icon returns s followed by a space, or nothing when emoji are disabled
+func (u *UI) icon(s string) string {
+	if !u.opts.Emoji || s == "" {
+		return ""
+	}
+	return s + " "
+}
+
+// bullet returns the list item marker
+func (u *UI) bullet() string {
+	if !u.opts.Emoji {
+		return "-"
+	}
+	return "•"
+}
+
+// ClearScreen clears the terminal screen. It does nothing when stdout is
+// not a terminal.
 func (u *UI) ClearScreen() {
+	if !StdoutIsTerminal() {
+		return
+	}
 	if runtime.GOOS == "windows" {
 		cmd := exec.Command("cmd", "/c", "cls")
 		cmd.Stdout = os.Stdout
@@ -48,40 +70,47 @@ func (u *UI) ShowHeader(version string) {
 	color.Blue.Println("                               v" + version + " developed by Anophel.com                    ")
 }
 
+// MenuItem is a selectable cleaner shown in the main menu
+type MenuItem struct {
+	Icon string
+	Text string
+}
+
 // ShowInstructions displays the usage instructions
-func (u *UI) ShowInstructions() {
-	color.Yellow.Println("\n📋 Select cleanup options:")
-	color.Yellow.Println("   • Enter numbers separated by commas (e.g., 1,3,5)")
-	color.Yellow.Println("   • Type 'all' to select all options")
-	color.Yellow.Println("   • Type 'exit' or '8' to quit")
-	color.Yellow.Println("\n🔧 Available Options:")
+func (u *UI) ShowInstructions(exitNumber int) {
+	color.Yellow.Printf("\n%sSelect cleanup options:\n", u.icon("📋"))
+	color.Yellow.Printf("   %s Enter numbers or names separated by commas (e.g., 1,3,5 or npm,docker)\n", u.bullet())
+	color.Yellow.Printf("   %s Type 'all' to select all options\n", u.bullet())
+	color.Yellow.Printf("   %s Type 'exit' or '%d' to quit\n", u.bullet(), exitNumber)
+	color.Yellow.Printf("\n%sAvailable Options:\n", u.icon("🔧"))
 }
 
-// ShowMenu displays the main menu with all options
-func (u *UI) ShowMenu() {
+// ShowMenu displays the main menu with the given cleaners followed by the
+// report and exit options
+func (u *UI) ShowMenu(version string, items []MenuItem) {
 	u.ClearScreen()
-	u.ShowHeader("0.2.0")
-	u.ShowInstructions()
+	u.ShowHeader(version)
+	u.ShowInstructions(len(items) + 2)
 
-	options := []struct {
+	type option struct {
 		icon  string
 		text  string
 		color func(a ...interface{}) string
-	}{
-		{"📦", "Clean npm cache", color.Green.Render},
-		{"🧶", "Clean yarn cache", color.Green.Render},
-		{"🐳", "Clean Docker cache", color.Green.Render},
-		{"🪟", "Clean WinSxS temp files", color.Green.Render},
-		{"🗑️", "Clean Windows temporary files", color.Green.Render},
-		{"📝", "Clean Windows error reporting chunks", color.Green.Render},
-		{"📊", "Show cache sizes", color.Cyan.Render},
-		{"🚪", "Exit", color.Red.Render},
 	}
 
+	options := make([]option, 0, len(items)+2)
+	for _, item := range items {
+		options = append(options, option{item.Icon, item.Text, color.Green.Render})
+	}
+	options = append(options,
+		option{"📊", "Show cache sizes", color.Cyan.Render},
+		option{"🚪", "Exit", color.Red.Render},
+	)
+
 	for i, opt := range options {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Printf("  %s %s%s\n",
 			color.Yellow.Sprintf("%d.", i+1),
-			opt.icon,
+			u.icon(opt.icon),
 			opt.color(opt.text))
 	}
 
@@ -91,40 +120,24 @@ func (u *UI) ShowMenu() {
 }
 
 // ShowSelectedOptions displays the selected cleanup options
-func (u *UI) ShowSelectedOptions(options []string) {
-	color.Cyan.Println("\n🎯 Selected options:")
-	for _, opt := range options {
-		switch opt {
-		case "1", "npm":
-			color.Green.Println("  • npm cache")
-		case "2", "yarn":
-			color.Green.Println("  • yarn cache")
-		case "3", "docker":
-			color.Green.Println("  • Docker cache")
-		case "4", "winsxs":
-			color.Green.Println("  • WinSxS temp files")
-		case "5", "wintemp":
-			color.Green.Println("  • Windows temporary files")
-		case "6", "winchunks":
-			color.Green.Println("  • Windows error reporting chunks")
-		case "7", "report":
-			color.Cyan.Println("  • Cache size report")
-		}
+func (u *UI) ShowSelectedOptions(labels []string) {
+	color.Cyan.Printf("\n%sSelected options:\n", u.icon("🎯"))
+	for _, label := range labels {
+		color.Green.Printf("  %s %s\n", u.bullet(), label)
 	}
 	fmt.Println()
 }
 
-// ShowAdminWarning displays the administrator privileges warning
-func (u *UI) ShowAdminWarning() {
-	color.Red.Print("\n🔒 Administrator Privileges Required 🔒\n")
+// ShowAdminWarning lists the cleaners that were skipped because they need
+// more privileges
+func (u *UI) ShowAdminWarning(skipped []string) {
+	color.Red.Printf("\n%sAdministrator Privileges Required\n", u.icon("🔒"))
 	color.Red.Println("========================================")
-	color.Yellow.Println("⚠️  This tool requires administrator privileges to clean system caches.")
-	color.Yellow.Println("Please run this tool as administrator.")
-	color.Yellow.Println("\nPress Enter to exit...")
-	if _, err := u.reader.ReadBytes('\n'); err != nil {
-		os.Exit(1)
+	color.Yellow.Printf("%sThese cleaners were skipped because they need more privileges:\n", u.icon("⚠️ "))
+	for _, s := range skipped {
+		color.Yellow.Printf("   %s %s\n", u.bullet(), s)
 	}
-	os.Exit(1)
+	color.Yellow.Println("Run this tool as administrator (or with sudo) to clean them.")
 }
 
 // ShowError displays an error message
@@ -134,62 +147,256 @@ func (u *UI) ShowError(err error) {
 
 // ShowSuccess displays a success message
 func (u *UI) ShowSuccess(msg string) {
-	color.Green.Printf("\n✨ %s\n", msg)
+	color.Green.Printf("\n%s%s\n", u.icon("✨"), msg)
 }
 
 // ShowWarning displays a warning message
 func (u *UI) ShowWarning(msg string) {
-	color.Yellow.Printf("\n⚠️  %s\n", msg)
+	color.Yellow.Printf("\n%s%s\n", u.icon("⚠️ "), msg)
 }
 
 // ShowInfo displays an info message
 func (u *UI) ShowInfo(msg string) {
-	color.Blue.Printf("\nℹ️  %s\n", msg)
+	color.Blue.Printf("\n%s%s\n", u.icon("ℹ️ "), msg)
+}
+
+// ShowGoodbye displays the farewell message
+func (u *UI) ShowGoodbye() {
+	color.Blue.Printf("\n%sGoodbye!\n", u.icon("👋"))
 }
 
 // ShowCleanupStart displays the cleanup start message
 func (u *UI) ShowCleanupStart() {
-	color.Yellow.Println("🔄 Starting cleanup process...")
+	color.Yellow.Printf("%sStarting cleanup process...\n", u.icon("🔄"))
 	fmt.Println()
 }
 
 // ShowCleanupComplete displays the cleanup completion message
 func (u *UI) ShowCleanupComplete(errCount int) {
 	if errCount > 0 {
-		color.Red.Printf("\n⚠️  Clearance completed with %d error(s). Some operations may have failed.\n", errCount)
+		color.Red.Printf("\n%sClearance completed with %d error(s). Some operations may have failed.\n", u.icon("⚠️ "), errCount)
 	} else {
-		color.Green.Println("\n✨ Clearance finished successfully!")
+		color.Green.Printf("\n%sClearance finished successfully!\n", u.icon("✨"))
 	}
 }
 
-// ReadInput reads user input
-func (u *UI) ReadInput() string {
-	color.Yellow.Print("\n👉 Enter your choice: ")
-	input, _ := u.reader.ReadString('\n')
-	return strings.TrimSpace(input)
+// ReadInput reads user input. It returns io.EOF once input is exhausted.
+func (u *UI) ReadInput() (string, error) {
+	color.Yellow.Printf("\n%sEnter your choice: ", u.icon("👉"))
+	input, err := u.reader.ReadString('\n')
+	if err != nil && input == "" {
+		fmt.Println()
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
 }
 
-// WaitForEnter waits for the user to press Enter
+// WaitForEnter waits for the user to press Enter. It returns at once when
+// stdin is not a terminal.
 func (u *UI) WaitForEnter() {
+	if !StdinIsTerminal() {
+		return
+	}
 	color.Cyan.Print("\nPress Enter to continue...")
 	if _, err := u.reader.ReadBytes('\n'); err != nil {
 		fmt.Println()
 	}
 }
 
+// PlanRow is a single line of a dry-run plan
+type PlanRow struct {
+	Target string
+	Size   string
+	Remove bool
+	Reason string
+}
+
+// ShowPlan displays what a cleaner would remove and keep without cleaning
+func (u *UI) ShowPlan(name string, rows []PlanRow, reclaimable string) {
+	color.Blue.Printf("\n%sDry run: %s\n", u.icon("🔍"), name)
+	if len(rows) == 0 {
+		color.Yellow.Println("  Nothing to clean")
+		return
+	}
+	for _, row := range rows {
+		if row.Remove {
+			color.Red.Printf("  - remove %s [%s] (%s)\n", row.Target, row.Size, row.Reason)
+		} else {
+			color.Green.Printf("  + keep   %s [%s] (%s)\n", row.Target, row.Size, row.Reason)
+		}
+	}
+	color.Cyan.Printf("  Would reclaim %s\n", reclaimable)
+}
+
+// SizeRow is a single cache in the cache size report
+type SizeRow struct {
+	Name  string
+	Label string
+	// Size is the size or the state of the cache, e.g. "Not running"
+	Size string
+	// Share is the percentage of its volume the cache takes, empty if unknown
+	Share  string
+	Volume string
+	// Part marks caches included in the row above, e.g. a Docker mode
+	Part     bool
+	Measured bool
+	Failed   bool
+}
+
+// VolumeRow is the capacity of a file system holding caches
+type VolumeRow struct {
+	Path  string
+	Free  string
+	Total string
+	Used  string
+}
+
 // ShowCacheSizeReport displays the cache size report
-func (u *UI) ShowCacheSizeReport(sizes map[string]string) {
-	color.Blue.Println("\n📊 Cache Size Report")
+func (u *UI) ShowCacheSizeReport(rows []SizeRow, total string, volumes []VolumeRow) {
+	color.Blue.Printf("\n%sCache Size Report\n", u.icon("📊"))
 	color.Blue.Println("===================")
 
-	for name, size := range sizes {
-		switch size {
-		case "Not found", "N/A", "Not installed", "Docker not running":
-			color.Yellow.Printf("%s: %s\n", name, size)
-		case "Error", "Error getting size":
-			color.Red.Printf("%s: %s\n", name, size)
+	color.Cyan.Printf("%-26s %16s %7s  %-16s %s\n", "Cache", "Size", "Disk", "Volume", "Description")
+	for _, row := range rows {
+		name := row.Name
+		if row.Part {
+			name = "  " + name
+		}
+		fmt.Printf("%-26s ", name)
+		switch {
+		case row.Failed:
+			color.Red.Printf("%16s", row.Size)
+		case row.Measured:
+			color.Green.Printf("%16s", row.Size)
 		default:
-			color.Green.Printf("%s: %s\n", name, size)
+			color.Yellow.Printf("%16s", row.Size)
+		}
+		fmt.Printf(" %7s  %-16s %s\n", row.Share, row.Volume, row.Label)
+	}
+	color.Cyan.Printf("%-26s %16s\n", "Total", total)
+
+	if len(volumes) > 0 {
+		color.Blue.Printf("\n%-26s %16s %12s %7s\n", "Volume", "Free", "Capacity", "Used")
+		for _, v := range volumes {
+			fmt.Printf("%-26s %16s %12s %7s\n", v.Path, v.Free, v.Total, v.Used)
+		}
+	}
+}
+
+// HistoryRow is a single cleaner entry of a recorded run
+type HistoryRow struct {
+	Time     string
+	Kind     string
+	Cleaner  string
+	Before   string
+	After    string
+	Freed    string
+	Duration string
+	Error    string
+}
+
+// ShowHistory displays recorded runs
+func (u *UI) ShowHistory(rows []HistoryRow) {
+	color.Blue.Printf("\n%sRun History\n", u.icon("📜"))
+	color.Blue.Println("==============")
+	if len(rows) == 0 {
+		color.Yellow.Println("No runs recorded yet")
+		return
+	}
+
+	color.Cyan.Printf("%-16s %-7s %-22s %10s %10s %10s %8s\n", "Time", "Kind", "Cleaner", "Before", "After", "Freed", "Took")
+	for _, row := range rows {
+		line := fmt.Sprintf("%-16s %-7s %-22s %10s %10s %10s %8s", row.Time, row.Kind, row.Cleaner, row.Before, row.After, row.Freed, row.Duration)
+		if row.Error != "" {
+			color.Red.Printf("%s  %s\n", line, row.Error)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// TrendRow summarizes one cleaner across recorded runs
+type TrendRow struct {
+	Cleaner     string
+	Runs        string
+	LastSize    string
+	Growth      string
+	TotalFreed  string
+	LastCleaned string
+}
+
+// ShowTrends displays how fast each cache regrows and how much space was reclaimed
+func (u *UI) ShowTrends(rows []TrendRow, totalFreed string) {
+	color.Blue.Printf("\n%sCache Trends\n", u.icon("📈"))
+	color.Blue.Println("===============")
+	if len(rows) == 0 {
+		color.Yellow.Println("No runs recorded yet")
+		return
+	}
+
+	color.Cyan.Printf("%-22s %6s %10s %12s %12s %-16s\n", "Cleaner", "Runs", "Size", "Growth/day", "Reclaimed", "Last cleaned")
+	for _, row := range rows {
+		fmt.Printf("%-22s %6s %10s %12s %12s %-16s\n", row.Cleaner, row.Runs, row.LastSize, row.Growth, row.TotalFreed, row.LastCleaned)
+	}
+	color.Green.Printf("\n%sTotal reclaimed: %s\n", u.icon("✨"), totalFreed)
+}
+
+// TopRow is a single cache entry in the largest entries report
+type TopRow struct {
+	Size     string
+	LastUsed string
+	Cleaner  string
+	Name     string
+}
+
+// ShowTopItems displays the largest cache entries, largest first
+func (u *UI) ShowTopItems(rows []TopRow) {
+	color.Blue.Printf("\n%sLargest Cache Entries\n", u.icon("🐘"))
+	color.Blue.Println("========================")
+	if len(rows) == 0 {
+		color.Yellow.Println("No cache entries found")
+		return
+	}
+
+	color.Cyan.Printf("%10s %-16s %-22s %s\n", "Size", "Last used", "Cleaner", "Entry")
+	for _, row := range rows {
+		fmt.Printf("%10s %-16s %-22s %s\n", row.Size, row.LastUsed, row.Cleaner, row.Name)
+	}
+}
+
+// ListRow is a single cleaner in the cleaner list
+type ListRow struct {
+	Key         string
+	Icon        string
+	Description string
+	InAll       bool
+	// Status is empty if the cleaner can run here, otherwise the reason it cannot
+	Status string
+}
+
+// ShowList displays the available cleaners and whether they can run here
+func (u *UI) ShowList(rows []ListRow) {
+	color.Blue.Printf("\n%sCleaners\n", u.icon("🧰"))
+	color.Blue.Println("===========")
+	color.Cyan.Printf("%-24s %-4s %-52s %s\n", "Key", "All", "Description", "Status")
+	for _, row := range rows {
+		all := ""
+		if row.InAll {
+			all = "yes"
+			if u.opts.Emoji {
+				all = "✓"
+			}
+		}
+		fmt.Printf("%-24s %-4s %-52s ", row.Key, all, u.icon(row.Icon)+row.Description)
+		if row.Status == "" {
+			color.Green.Println("available")
+		} else {
+			color.Yellow.Println(row.Status)
 		}
 	}
 }
+
+// ShowVersion displays the build information
+func (u *UI) ShowVersion(version, commit, date string) {
+	fmt.Printf("clearance %s (commit %s, built %s)\n", version, commit, date)
+}
@@ -2,122 +2,499 @@ package main
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/abdorrahmani/clearance/internal/cleaner"
+	"github.com/abdorrahmani/clearance/internal/config"
+	"github.com/abdorrahmani/clearance/internal/history"
 	"github.com/abdorrahmani/clearance/internal/reporter"
 	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
 	"github.com/abdorrahmani/clearance/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-func executeCleanup(ui *ui.UI, options []string) error {
-	ctx := context.Background()
+// Build information, set by goreleaser through -ldflags
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+var (
+	cfg         *config.Config
+	configPath  string
+	profileName string
+	noColor     bool
+	noEmoji     bool
+	noTUI       bool
+)
 
-	// Handle exit option first
-	if len(options) == 1 && (options[0] == "8" || strings.ToLower(options[0]) == "exit") {
-		ui.ShowInfo("Goodbye! 👋")
-		os.Exit(0)
+// configureUI applies --no-color and --no-emoji on top of what the
+// terminal supports
+func configureUI() {
+	opts := ui.DetectOptions()
+	if noColor {
+		opts.Color = false
+	}
+	if noEmoji {
+		opts.Emoji = false
 	}
+	ui.Configure(opts)
+}
 
-	ui.ShowSelectedOptions(options)
+var (
+	dryRun               bool
+	dockerImageAge       time.Duration
+	dockerKeepStorage    string
+	dockerKeepLabels     []string
+	dockerKeepNames      []string
+	dockerKeepRepos      []string
+	dockerKeepRecent     int
+	containerdNamespaces []string
+)
 
-	// Handle cache size reporting
-	if len(options) == 1 && (options[0] == "7" || options[0] == "report") {
-		reporter := reporter.NewCacheReporter()
-		sizes := reporter.GetCacheSizes()
-		ui.ShowCacheSizeReport(sizes)
-		return nil
+// applySettings fills in the cleanup flags from the configuration file,
+// leaving flags given on the command line untouched
+func applySettings(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	changed := func(name string) bool {
+		flag := flags.Lookup(name)
+		return flag != nil && flag.Changed
+	}
+
+	s := cfg.Settings
+	if s.Docker.ImageAge != nil && !changed("docker-image-age") {
+		dockerImageAge = *s.Docker.ImageAge
+	}
+	if s.Docker.KeepStorage != "" && !changed("docker-keep-storage") {
+		dockerKeepStorage = s.Docker.KeepStorage
+	}
+	if len(s.Docker.KeepLabels) > 0 && !changed("docker-keep-label") {
+		dockerKeepLabels = s.Docker.KeepLabels
+	}
+	if len(s.Docker.KeepNames) > 0 && !changed("docker-keep-name") {
+		dockerKeepNames = s.Docker.KeepNames
+	}
+	if len(s.Docker.KeepRepos) > 0 && !changed("docker-keep-repo") {
+		dockerKeepRepos = s.Docker.KeepRepos
+	}
+	if s.Docker.KeepRecent > 0 && !changed("docker-keep-recent") {
+		dockerKeepRecent = s.Docker.KeepRecent
+	}
+	if len(s.Docker.Namespaces) > 0 && !changed("containerd-namespace") {
+		containerdNamespaces = s.Docker.Namespaces
+	}
+	if s.Output.DryRun && !changed("dry-run") {
+		dryRun = true
+	}
+	if s.Watch.MinFree != "" && !changed("min-free") {
+		watchMinFree = s.Watch.MinFree
+	}
+	if s.Watch.Path != "" && !changed("path") {
+		watchPath = s.Watch.Path
+	}
+	if s.Watch.Interval > 0 && !changed("interval") {
+		watchInterval = s.Watch.Interval
+	}
+	if s.Watch.Cooldown > 0 && !changed("cooldown") {
+		watchCooldown = s.Watch.Cooldown
+	}
+}
+
+// pluginOptions returns the plugin timeouts from the configuration file
+func pluginOptions() clearance.PluginOptions {
+	options := clearance.DefaultPluginOptions()
+	if t := cfg.Settings.Plugins.Timeout; t > 0 {
+		options.Timeout = t
+	}
+	if t := cfg.Settings.Plugins.CleanTimeout; t > 0 {
+		options.CleanTimeout = t
+	}
+	return options
+}
+
+// newRegistry builds the cleaner registry from the command line flags and
+// the configuration file
+func newRegistry() (*clearance.Registry, error) {
+	dockerOptions := clearance.DefaultDockerOptions()
+	dockerOptions.ImageAge = dockerImageAge
+	dockerOptions.Protect = clearance.DockerProtection{
+		Labels:       dockerKeepLabels,
+		Names:        dockerKeepNames,
+		Repositories: dockerKeepRepos,
+		KeepRecent:   dockerKeepRecent,
+	}
+	if dockerKeepStorage != "" {
+		keep, err := clearance.ParseSize(dockerKeepStorage)
+		if err != nil {
+			return nil, err
+		}
+		dockerOptions.KeepStorage = keep
+	}
+
+	builtin := clearance.NewDefaultRegistry(clearance.RegistryOptions{ContainerdNamespaces: containerdNamespaces})
+	taken := make(map[string]bool)
+	for _, key := range builtin.Keys() {
+		taken[key] = true
 	}
+	var custom []clearance.CustomOptions
+	for _, cs := range cfg.Settings.Custom {
+		options := cs.Options()
+		if err := options.Validate(); err != nil {
+			return nil, err
+		}
+		if taken[options.Name] {
+			return nil, fmt.Errorf("custom cleaner %q clashes with a built-in cleaner", options.Name)
+		}
+		taken[options.Name] = true
+		custom = append(custom, options)
+	}
+
+	pluginOptions := pluginOptions()
+	var plugins []clearance.PluginEntry
+	if !cfg.Settings.Plugins.Disabled {
+		var skip []string
+		for _, name := range clearance.PluginNames(os.Getenv("PATH")) {
+			if taken[name] {
+				ui.NewUI().ShowWarning(fmt.Sprintf("Ignoring plugin %s: the name is already used by another cleaner", name))
+				skip = append(skip, name)
+			}
+		}
+		var errs []error
+		plugins, errs = clearance.DiscoverPlugins(context.Background(), os.Getenv("PATH"), pluginOptions, skip...)
+		for _, err := range errs {
+			ui.NewUI().ShowWarning(fmt.Sprintf("%v, skipping it", err))
+		}
+	}
+
+	paths := make(map[string]clearance.PathOptions)
+	for key, cs := range cfg.Settings.Cleaners {
+		options := clearance.PathOptions{Paths: cs.Paths}
+		if cs.MinAge != nil {
+			options.MinAge = *cs.MinAge
+		}
+		paths[key] = options
+	}
+
+	registry := clearance.NewDefaultRegistry(clearance.RegistryOptions{
+		Docker:               dockerOptions,
+		ContainerdNamespaces: containerdNamespaces,
+		Paths:                paths,
+		Custom:               custom,
+		Plugins:              plugins,
+		PluginOptions:        pluginOptions,
+	})
+	for key, cs := range cfg.Settings.Cleaners {
+		if cs.Enabled == nil {
+			continue
+		}
+		if !registry.SetEnabled(key, *cs.Enabled) {
+			return nil, fmt.Errorf("unknown cleaner %q in configuration", key)
+		}
+	}
+	return registry, nil
+}
+
+// showReport displays the cache size report for every registered cleaner
+// and records the measured sizes in the run history
+func showReport(ui *ui.UI, registry *clearance.Registry) {
+	report := measureReport(ui, registry)
+	report.Sort(reportSort)
+	ui.ShowCacheSizeReport(sizeRows(report), clearance.FormatSize(report.Total), volumeRows(report))
+}
+
+// newReporter creates a reporter for every registered cleaner
+func newReporter(registry *clearance.Registry) *reporter.CacheReporter {
+	r := reporter.NewCacheReporter()
+	for _, e := range registry.Entries() {
+		if e.Part != "" {
+			r.AddPartSource(e.Key, e.Description, e.Part, e.New())
+		} else {
+			r.AddSource(e.Key, e.Description, e.New())
+		}
+	}
+	return r
+}
+
+// measureReport measures every registered cleaner and records the sizes in
+// the run history
+func measureReport(ui *ui.UI, registry *clearance.Registry) *reporter.Report {
+	run := history.NewRun(history.KindReport)
+	reporter := newReporter(registry)
+	report := reporter.GetReport()
+
+	measured := reporter.GetBytes()
+	for _, e := range registry.Entries() {
+		if size, ok := measured[e.Key]; ok {
+			run.Add(history.Entry{Cleaner: e.Key, BytesBefore: size, BytesAfter: size})
+		}
+	}
+	run.Finish()
+	recordRun(ui, run)
+	return report
+}
+
+// recordRun appends a run to the history store, warning if it cannot be saved
+func recordRun(ui *ui.UI, run *history.Run) {
+	store, err := history.NewDefaultStore()
+	if err == nil {
+		err = store.Append(run)
+	}
+	if err != nil {
+		ui.ShowWarning(fmt.Sprintf("Could not record run history: %v", err))
+	}
+}
 
-	cleaners := []cleaner.Cleaner{}
+// showPlans displays what each cleaner would do without cleaning
+func showPlans(ctx context.Context, u *ui.UI, cleaners []clearance.Cleaner) {
+	for _, c := range cleaners {
+		plan, err := clearance.PlanCleaner(ctx, c)
+		var notSupported *errors.ErrNotSupported
+		if stderrors.As(err, &notSupported) {
+			u.ShowWarning(fmt.Sprintf("Dry run is not supported by %s", c.GetName()))
+			continue
+		}
+		if err != nil {
+			u.ShowError(err)
+			continue
+		}
+		rows := make([]ui.PlanRow, 0, len(plan.Items))
+		for _, item := range plan.Items {
+			size := "unknown"
+			if item.Size >= 0 {
+				size = clearance.FormatSize(item.Size)
+			}
+			rows = append(rows, ui.PlanRow{Target: item.Target, Size: size, Remove: item.Remove, Reason: item.Reason})
+		}
+		u.ShowPlan(plan.CleanerName, rows, clearance.FormatSize(plan.Reclaimable()))
+	}
+}
+
+// executeCleanup runs the selected cleaners. Cleaners that are not supported
+// here or need privileges the process lacks are skipped; the others'
+// failures are returned together as *errors.ErrCleanupFailures. Selecting
+// the exit option returns errors.ErrQuit.
+func executeCleanup(ctx context.Context, ui *ui.UI, registry *clearance.Registry, options []string) error {
+	reportOption := strconv.Itoa(len(registry.Entries()) + 1)
+	exitOption := strconv.Itoa(len(registry.Entries()) + 2)
+
+	var labels []string
+	cleaners := []clearance.Cleaner{}
 	for _, opt := range options {
+		opt = strings.ToLower(strings.TrimSpace(opt))
 		switch opt {
-		case "1", "npm":
-			cleaners = append(cleaners, cleaner.NewNPMCleaner())
-		case "2", "yarn":
-			cleaners = append(cleaners, cleaner.NewYarnCleaner())
-		case "3", "docker":
-			cleaners = append(cleaners, cleaner.NewDockerCleaner())
-		case "4", "winsxs":
-			cleaners = append(cleaners, cleaner.NewWindowsCleaner("winsxs"))
-		case "5", "wintemp":
-			cleaners = append(cleaners, cleaner.NewWindowsCleaner("wintemp"))
-		case "6", "winchunks":
-			cleaners = append(cleaners, cleaner.NewWindowsCleaner("winchunks"))
-		case "7", "report":
-			reporter := reporter.NewCacheReporter()
-			sizes := reporter.GetCacheSizes()
-			ui.ShowCacheSizeReport(sizes)
+		case reportOption, "report":
+			ui.ShowSelectedOptions([]string{"Cache size report"})
+			showReport(ui, registry)
 			return nil
-		case "8", "exit":
-			ui.ShowInfo("Goodbye! 👋")
-			os.Exit(0)
+		case exitOption, "exit":
+			ui.ShowGoodbye()
+			return errors.ErrQuit
+		}
+		if entry, ok := registry.Lookup(opt); ok {
+			labels = append(labels, entry.Description)
+			cleaners = append(cleaners, entry.New())
 		}
 	}
 
+	ui.ShowSelectedOptions(labels)
+
 	if len(cleaners) == 0 {
 		return errors.NewErrNotSupported("cleanup", "no valid cleanup options selected")
 	}
 
-	if err := cleaner.CheckAdminPrivileges(); err != nil {
-		ui.ShowAdminWarning()
+	if dryRun {
+		showPlans(ctx, ui, cleaners)
+		return nil
+	}
+
+	cleanLock, err := lockCleaning()
+	if err != nil {
 		return err
 	}
+	defer cleanLock.Release()
 
 	ui.ShowCleanupStart()
 
-	var errs []error
+	run := newCleanupRun()
 	for _, c := range cleaners {
-		if err := c.Clean(ctx); err != nil {
-			ui.ShowError(err)
-			errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+		outcome := run.clean(ctx, c)
+		switch {
+		case !outcome.Required.IsZero():
+			ui.ShowWarning(fmt.Sprintf("Skipped %s: %s required", c.GetName(), outcome.Required))
+		case outcome.InUse != nil:
+			ui.ShowWarning(fmt.Sprintf("Skipped %s: in use, %s", c.GetName(), outcome.InUse.Reason))
+		case outcome.Skipped != nil:
+			var notSupported *errors.ErrNotSupported
+			stderrors.As(outcome.Skipped, &notSupported)
+			ui.ShowWarning(fmt.Sprintf("Skipped %s: %s", c.GetName(), notSupported.Reason))
+		case outcome.Failed != nil:
+			ui.ShowError(outcome.Result.Error)
 		}
 	}
+	run.finish(ui)
 
-	ui.ShowCleanupComplete(len(errs))
-	if len(errs) > 0 {
-		return errors.NewErrCleanupFailed("all", "some cleanup operations failed")
+	if err := ctx.Err(); err != nil {
+		ui.ShowWarning("Cleanup cancelled")
+		return err
 	}
 
-	return nil
+	if len(run.needAdmin) > 0 {
+		ui.ShowAdminWarning(run.needAdmin)
+	}
+	ui.ShowCleanupComplete(len(run.failures))
+	if freed := run.freed(); freed > 0 {
+		ui.ShowSuccess(fmt.Sprintf("Freed %s", clearance.FormatSize(freed)))
+	}
+	return run.err(ctx)
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "clearance",
 	Short: "A lightweight CLI tool to clean up development caches",
 	Long: `Clearance is a CLI tool that helps free up disk space by cleaning various development caches.
-It can clean npm, yarn, Docker, and Windows system temp files.`,
+It can clean npm, yarn, Docker, and Windows system temp files.
+
+Run without a command from a terminal to choose cleaners from a menu.`,
+	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfg = config.NewConfig(version, commit, date)
+		if err := cfg.Load(configPath, profileName); err != nil {
+			return err
+		}
+		applySettings(cmd)
+		// Usage is only helpful for mistakes on the command line
+		cmd.SilenceUsage = true
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ui := ui.NewUI()
+		if !ui.StdinIsTerminal() {
+			return cmd.Help()
+		}
+		return runInteractive(cmd.Context())
+	},
+}
 
-		for {
-			ui.ShowMenu()
-			input := ui.ReadInput()
-			if input == "" {
-				continue
-			}
+var interactiveCmd = &cobra.Command{
+	Use:   "interactive",
+	Short: "Choose cleaners from a menu",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInteractive(cmd.Context())
+	},
+}
 
-			var options []string
-			if strings.ToLower(input) == "all" {
-				options = []string{"1", "2", "3", "4", "5", "6"}
-			} else if strings.ToLower(input) == "exit" {
-				options = []string{"8"}
-			} else {
-				options = strings.Split(input, ",")
-			}
+// waitFor runs a blocking prompt, giving up when ctx is cancelled
+func waitFor(ctx context.Context, prompt func()) error {
+	done := make(chan struct{})
+	go func() {
+		prompt()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runInteractive shows the menu until the user exits, input ends or ctx is
+// cancelled
+func runInteractive(ctx context.Context) error {
+	registry, err := newRegistry()
+	if err != nil {
+		return err
+	}
+
+	var items []ui.MenuItem
+	for _, e := range registry.Entries() {
+		items = append(items, ui.MenuItem{Icon: e.Icon, Text: "Clean " + e.Description})
+	}
+
+	// The full-screen interface cannot show dry-run plans
+	fullScreen := !noTUI && !dryRun && ui.TUISupported()
+	ui := ui.NewUI()
+
+	if fullScreen {
+		if shown, err := runTUI(ctx, ui, registry); shown {
+			return err
+		}
+	}
+
+	for {
+		ui.ShowMenu(cfg.GetVersion(), items)
+		var input string
+		var readErr error
+		if err := waitFor(ctx, func() { input, readErr = ui.ReadInput() }); err != nil {
+			return err
+		}
+		if readErr != nil {
+			return nil
+		}
+		if input == "" {
+			continue
+		}
 
-			if err := executeCleanup(ui, options); err != nil {
-				ui.ShowError(err)
+		var options []string
+		if strings.ToLower(input) == "all" {
+			for _, e := range registry.All() {
+				options = append(options, e.Key)
 			}
+		} else {
+			options = strings.Split(input, ",")
+		}
 
-			ui.WaitForEnter()
+		err := executeCleanup(ctx, ui, registry, options)
+		if stderrors.Is(err, errors.ErrQuit) {
+			return nil
 		}
-	},
+		if stderrors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			ui.ShowError(err)
+		}
+
+		if err := waitFor(ctx, ui.WaitForEnter); err != nil {
+			return err
+		}
+	}
+}
+
+// addCleanupFlags registers the flags that control what gets cleaned
+func addCleanupFlags(flags *pflag.FlagSet) {
+	flags.DurationVar(&dockerImageAge, "docker-image-age", clearance.DefaultDockerOptions().ImageAge, "only prune unused Docker images older than this")
+	flags.StringVar(&dockerKeepStorage, "docker-keep-storage", "", "amount of Docker build cache to keep, e.g. 5GB")
+	flags.StringSliceVar(&containerdNamespaces, "containerd-namespace", []string{"default"}, "containerd namespaces to clean through nerdctl")
+	flags.BoolVar(&dryRun, "dry-run", false, "show what would be removed without removing anything")
+	flags.StringSliceVar(&dockerKeepLabels, "docker-keep-label", nil, "never remove Docker images or volumes with this label, e.g. keep=true")
+	flags.StringSliceVar(&dockerKeepNames, "docker-keep-name", nil, "never remove Docker images or volumes matching this name pattern, e.g. postgres*")
+	flags.StringSliceVar(&dockerKeepRepos, "docker-keep-repo", nil, "never remove images of this Docker repository")
+	flags.IntVar(&dockerKeepRecent, "docker-keep-recent", 0, "keep the N most recent images of each Docker repository")
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "configuration file (default $XDG_CONFIG_HOME/clearance/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "configuration profile to apply, e.g. ci")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "print plain text instead of emoji")
+	rootCmd.PersistentFlags().BoolVar(&noTUI, "no-tui", false, "use the numbered menu instead of the full-screen interface")
+	cobra.OnInitialize(configureUI)
+	addCleanupFlags(rootCmd.PersistentFlags())
+	rootCmd.AddCommand(interactiveCmd)
 }
 
 func main() {
@@ -135,7 +512,12 @@ func main() {
 		}
 	}
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	// Ctrl+C, or SIGTERM from a service manager, cancels the running
+	// cleaners instead of killing the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(errors.ExitCode(err))
 	}
 }
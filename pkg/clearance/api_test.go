@@ -0,0 +1,101 @@
+package clearance_test
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+)
+
+// This file fails to compile when the public API changes incompatibly,
+// including the types aliased from internal packages. Update it only
+// together with a new major version.
+
+// Each interface must accept a type with exactly its current methods, so
+// adding a method to one breaks the build
+type (
+	cleanerAPI      struct{}
+	measurerAPI     struct{}
+	plannerAPI      struct{}
+	checkerAPI      struct{}
+	locatorAPI      struct{}
+	sharerAPI       struct{}
+	inUseCheckerAPI struct{}
+	elevatedAPI     struct{}
+	listerAPI       struct{}
+)
+
+func (cleanerAPI) Clean(context.Context) error                   { return nil }
+func (cleanerAPI) GetSize(context.Context) (string, error)       { return "", nil }
+func (cleanerAPI) GetName() string                               { return "" }
+func (measurerAPI) Measure(context.Context) (int64, error)       { return 0, nil }
+func (plannerAPI) Plan(context.Context) (*clearance.Plan, error) { return nil, nil }
+func (checkerAPI) Available(context.Context) error               { return nil }
+func (locatorAPI) CachePaths() []string                          { return nil }
+func (sharerAPI) SharedCache() bool                              { return false }
+func (inUseCheckerAPI) InUse(context.Context) error              { return nil }
+func (elevatedAPI) RequiredPrivileges(context.Context) clearance.Privileges {
+	return clearance.Privileges{}
+}
+func (listerAPI) Items(context.Context) ([]clearance.CacheItem, error) { return nil, nil }
+
+var (
+	_ clearance.Cleaner      = cleanerAPI{}
+	_ clearance.Measurer     = measurerAPI{}
+	_ clearance.Planner      = plannerAPI{}
+	_ clearance.Checker      = checkerAPI{}
+	_ clearance.Locator      = locatorAPI{}
+	_ clearance.Sharer       = sharerAPI{}
+	_ clearance.InUseChecker = inUseCheckerAPI{}
+	_ clearance.Elevated     = elevatedAPI{}
+	_ clearance.Lister       = listerAPI{}
+)
+
+// Methods of the exported types
+var (
+	_ func(*clearance.Registry, clearance.Entry)                        = (*clearance.Registry).Register
+	_ func(*clearance.Registry) []clearance.Entry                       = (*clearance.Registry).Entries
+	_ func(*clearance.Registry, string) (clearance.Entry, bool)         = (*clearance.Registry).Lookup
+	_ func(*clearance.Registry) []clearance.Entry                       = (*clearance.Registry).All
+	_ func(*clearance.Registry, string, bool) bool                      = (*clearance.Registry).SetEnabled
+	_ func(*clearance.Registry) []string                                = (*clearance.Registry).Keys
+	_ func(*clearance.Plan) int64                                       = (*clearance.Plan).Reclaimable
+	_ func(clearance.CleanResult) int64                                 = clearance.CleanResult.Freed
+	_ func(clearance.Privileges) bool                                   = clearance.Privileges.IsZero
+	_ func(clearance.Privileges) string                                 = clearance.Privileges.String
+	_ func(clearance.PluginEntry) string                                = clearance.PluginEntry.Name
+	_ func(clearance.PluginEntry) string                                = clearance.PluginEntry.Path
+	_ func(clearance.PluginEntry) string                                = clearance.PluginEntry.Description
+	_ func(clearance.PluginEntry) string                                = clearance.PluginEntry.Icon
+	_ func(clearance.PluginEntry) bool                                  = clearance.PluginEntry.InAll
+	_ func(context.Context, io.Writer) context.Context                  = clearance.WithOutput
+	_ func(context.Context, clearance.Cleaner) clearance.CleanResult    = clearance.Execute
+	_ func(context.Context, clearance.Cleaner) (*clearance.Plan, error) = clearance.PlanCleaner
+)
+
+// Fields of the exported structs, by name and type
+var (
+	_ = clearance.Entry{Key: "", Description: "", Icon: "", InAll: false, Part: "", New: func() clearance.Cleaner { return nil }}
+	_ = clearance.CleanResult{CleanerName: "", Error: nil, SizeBefore: "", SizeAfter: "", BytesBefore: 0, BytesAfter: 0, Duration: time.Duration(0)}
+	_ = clearance.Plan{CleanerName: "", Items: []clearance.PlanItem{{Target: "", Size: 0, Remove: false, Reason: ""}}}
+	_ = clearance.Privileges{Admin: false, Groups: []string(nil)}
+	_ = clearance.InUseOptions{Processes: []string(nil), LockFiles: []string(nil)}
+	_ = clearance.DockerOptions{ImageAge: time.Duration(0), KeepStorage: 0, Protect: clearance.DockerProtection{
+		Labels: []string(nil), Names: []string(nil), Repositories: []string(nil), KeepRecent: 0,
+	}}
+	_ = clearance.PathOptions{Paths: []string(nil), MinAge: time.Duration(0)}
+	_ = clearance.CustomOptions{
+		Name: "", Description: "", Icon: "", Paths: []string(nil), Root: "", MinAge: time.Duration(0),
+		Pre: "", Post: "", InAll: false, InUse: clearance.InUseOptions{},
+	}
+	_ = clearance.PluginOptions{Timeout: time.Duration(0), CleanTimeout: time.Duration(0)}
+	_ = clearance.RegistryOptions{
+		Docker: clearance.DockerOptions{}, ContainerdNamespaces: []string(nil), Paths: map[string]clearance.PathOptions(nil),
+		Custom: []clearance.CustomOptions(nil), Plugins: []clearance.PluginEntry(nil), PluginOptions: clearance.PluginOptions{},
+	}
+	_ = clearance.DiskNode{Name: "", Path: "", Size: 0, Modified: time.Time{}, IsDir: false, Children: []*clearance.DiskNode(nil), Err: nil}
+	_ = clearance.CacheItem{Name: "", Size: 0, LastUsed: time.Time{}}
+	_ = clearance.Size{Key: "", Description: "", Bytes: 0, Status: "", Error: nil}
+	_ = clearance.Item{Cleaner: "", CacheItem: clearance.CacheItem{}}
+)
@@ -0,0 +1,385 @@
+package clearance
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/abdorrahmani/clearance/internal/cleaner"
+	"github.com/abdorrahmani/clearance/internal/plugin"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// Cleaner defines the interface for cache cleaning operations
+type Cleaner = cleaner.Cleaner
+
+// Measurer is implemented by cleaners that can measure their cache in bytes
+type Measurer = cleaner.Measurer
+
+// Planner is implemented by cleaners that can report what Clean would remove
+type Planner = cleaner.Planner
+
+// Checker is implemented by cleaners that can tell whether they can run on
+// this machine
+type Checker = cleaner.Checker
+
+// Locator is implemented by cleaners whose caches are directories on disk
+type Locator = cleaner.Locator
+
+// Sharer is implemented by cleaners whose cache belongs to the machine
+// rather than the user running them, such as a container engine's
+type Sharer = cleaner.Sharer
+
+// InUseChecker is implemented by cleaners that can tell whether the tool
+// owning their cache is using it
+type InUseChecker = cleaner.InUseChecker
+
+// InUseOptions describes the processes and lock files that mark a cache as
+// in use
+type InUseOptions = cleaner.InUseOptions
+
+// Elevated is implemented by cleaners that may need more than user privileges
+type Elevated = cleaner.Elevated
+
+// Privileges describes what a cleaner needs beyond a normal user account
+type Privileges = cleaner.Privileges
+
+// Plan describes what a cleaner would do without doing it
+type Plan = cleaner.Plan
+
+// PlanItem describes a single target a cleaner would remove or keep
+type PlanItem = cleaner.PlanItem
+
+// CleanResult represents the result of a cleaning operation
+type CleanResult = cleaner.CleanResult
+
+// Entry describes a cleaner that can be selected by key
+type Entry = cleaner.Entry
+
+// Registry holds the selectable cleaners in menu order
+type Registry = cleaner.Registry
+
+// RegistryOptions configures the built-in cleaners
+type RegistryOptions = cleaner.RegistryOptions
+
+// DockerOptions configures the Docker, Podman and nerdctl cleaners
+type DockerOptions = cleaner.DockerOptions
+
+// DockerProtection lists Docker images and volumes that must never be removed
+type DockerProtection = cleaner.DockerProtection
+
+// PathOptions overrides where a path-based cleaner looks and what it removes
+type PathOptions = cleaner.PathOptions
+
+// CustomOptions declares a cleaner for a set of paths
+type CustomOptions = cleaner.CustomOptions
+
+// PluginOptions configures how external cleaner plugins are run
+type PluginOptions = cleaner.PluginOptions
+
+// PluginEntry is a discovered plugin together with its description, as
+// returned by DiscoverPlugins
+type PluginEntry = cleaner.PluginEntry
+
+// DiskNode is a file or directory measured by ScanTree
+type DiskNode = cleaner.DiskNode
+
+// CacheItem is an individual entry in a cache, such as a package or an image
+type CacheItem = cleaner.CacheItem
+
+// Lister is implemented by cleaners that can list the individual entries in
+// their cache
+type Lister = cleaner.Lister
+
+// Docker cleanup modes
+const (
+	DockerModeContainers = cleaner.DockerModeContainers
+	DockerModeDangling   = cleaner.DockerModeDangling
+	DockerModeImages     = cleaner.DockerModeImages
+	DockerModeNetworks   = cleaner.DockerModeNetworks
+	DockerModeBuildCache = cleaner.DockerModeBuildCache
+	DockerModeVolumes    = cleaner.DockerModeVolumes
+)
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return cleaner.NewRegistry()
+}
+
+// NewDefaultRegistry creates a registry with all built-in cleaners
+func NewDefaultRegistry(options RegistryOptions) *Registry {
+	return cleaner.NewDefaultRegistry(options)
+}
+
+// DefaultDockerOptions returns the default Docker cleanup options
+func DefaultDockerOptions() DockerOptions {
+	return cleaner.DefaultDockerOptions()
+}
+
+// DefaultPluginOptions returns the default plugin timeouts
+func DefaultPluginOptions() PluginOptions {
+	return cleaner.DefaultPluginOptions()
+}
+
+// NewCustomCleaner creates a cleaner for the paths in options
+func NewCustomCleaner(options CustomOptions) Cleaner {
+	return cleaner.NewCustomCleaner(options)
+}
+
+// DiscoverPlugins finds the clearance-cleaner-* executables on the given
+// PATH list and asks each to describe itself. Plugins that fail or whose
+// names cannot be selected are left out and reported in the returned errors.
+// Names in skip are not considered.
+func DiscoverPlugins(ctx context.Context, pathList string, options PluginOptions, skip ...string) ([]PluginEntry, []error) {
+	taken := make(map[string]bool)
+	for _, name := range skip {
+		taken[name] = true
+	}
+	var found []*plugin.Plugin
+	var errs []error
+	for _, p := range plugin.Find(pathList) {
+		switch {
+		case taken[p.Name]:
+		case !cleaner.ValidKey(p.Name):
+			errs = append(errs, fmt.Errorf("plugin %q: name must be lowercase without spaces or commas, and not a number or one of all, report and exit", p.Name))
+		default:
+			found = append(found, p)
+		}
+	}
+	entries, describeErrs := cleaner.DescribePlugins(ctx, found, options)
+	return entries, append(errs, describeErrs...)
+}
+
+// PluginNames returns the names of the usable plugins on the given PATH list
+// without running them
+func PluginNames(pathList string) []string {
+	var names []string
+	for _, p := range plugin.Find(pathList) {
+		if cleaner.ValidKey(p.Name) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// WithOutput returns ctx sending the progress messages of the cleaners run
+// with it, and the output of the commands and plugins they run, to w. Pass
+// io.Discard to silence them. Without it they go to os.Stdout.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return cleaner.WithOutput(ctx, w)
+}
+
+// Execute runs a cleaner, measuring the cache before and after when the
+// cleaner supports it
+func Execute(ctx context.Context, c Cleaner) CleanResult {
+	return cleaner.Execute(ctx, c)
+}
+
+// PlanCleaner returns what c would remove. It returns *errors.ErrNotSupported
+// if c cannot plan.
+func PlanCleaner(ctx context.Context, c Cleaner) (*Plan, error) {
+	planner, ok := c.(Planner)
+	if !ok {
+		return nil, errors.NewErrNotSupported("dry run", c.GetName()+" cannot report what it would remove")
+	}
+	return planner.Plan(ctx)
+}
+
+// Available returns nil if c can run on this machine, or an error explaining
+// why not. Cleaners that do not implement Checker are assumed available.
+func Available(ctx context.Context, c Cleaner) error {
+	checker, ok := c.(Checker)
+	if !ok {
+		return nil
+	}
+	return checker.Available(ctx)
+}
+
+// Size is the measured size of a registry entry
+type Size struct {
+	Key         string
+	Description string
+	// Bytes is the size in bytes, 0 if the cache does not exist, or -1 if it
+	// cannot be measured
+	Bytes int64
+	// Status is the human-readable size or state, e.g. "1.2 GB" or "Not running"
+	Status string
+	Error  error
+}
+
+// Measure returns the size of c in bytes, or -1 if c cannot measure itself
+func Measure(ctx context.Context, c Cleaner) (int64, error) {
+	measurer, ok := c.(Measurer)
+	if !ok {
+		return -1, nil
+	}
+	return measurer.Measure(ctx)
+}
+
+// Scan measures every entry in order
+func Scan(ctx context.Context, entries []Entry) []Size {
+	sizes := make([]Size, 0, len(entries))
+	for _, e := range entries {
+		sizes = append(sizes, MeasureEntry(ctx, e))
+	}
+	return sizes
+}
+
+// MeasureEntry measures a single registry entry. It is safe to call for
+// several entries at once.
+func MeasureEntry(ctx context.Context, e Entry) Size {
+	c := e.New()
+	size := Size{Key: e.Key, Description: e.Description}
+	size.Bytes, size.Error = Measure(ctx, c)
+	switch {
+	case size.Error != nil:
+		size.Bytes, size.Status = -1, "Error"
+	case size.Bytes > 0:
+		size.Status = FormatSize(size.Bytes)
+	default:
+		// GetSize explains why there is nothing to measure
+		size.Status, size.Error = c.GetSize(ctx)
+	}
+	return size
+}
+
+// FormatSize formats a size in bytes for display, e.g. "1.5 GB"
+func FormatSize(size int64) string {
+	return cleaner.FormatSize(size)
+}
+
+// ParseSize parses a size such as "5GB", "512M" or "1024" into bytes
+func ParseSize(s string) (int64, error) {
+	return cleaner.ParseSize(s)
+}
+
+// CheckAdminPrivileges returns *errors.ErrAdminRequired unless the process
+// runs with administrator privileges, or as root on Unix
+func CheckAdminPrivileges() error {
+	return cleaner.CheckAdminPrivileges()
+}
+
+// RequiredPrivileges returns the privileges c needs to clean, none if it
+// does not implement Elevated
+func RequiredPrivileges(ctx context.Context, c Cleaner) Privileges {
+	return cleaner.RequiredPrivileges(ctx, c)
+}
+
+// CheckInUse returns *errors.ErrInUse if c's cache is in use by the tool
+// that owns it
+func CheckInUse(ctx context.Context, c Cleaner) error {
+	return cleaner.CheckInUse(ctx, c)
+}
+
+// IsShared reports whether c's cache is shared by every user of the machine
+func IsShared(c Cleaner) bool {
+	return cleaner.IsShared(c)
+}
+
+// CheckPrivileges returns *errors.ErrAdminRequired if the process lacks p
+func CheckPrivileges(p Privileges) error {
+	return cleaner.CheckPrivileges(p)
+}
+
+// CachePaths returns the directories c cleans, or ErrNotSupported if its
+// cache is not a set of directories, e.g. for Docker
+func CachePaths(c Cleaner) ([]string, error) {
+	locator, ok := c.(Locator)
+	if !ok {
+		return nil, errors.NewErrNotSupported("explore", c.GetName()+" does not keep its cache in directories")
+	}
+	return locator.CachePaths(), nil
+}
+
+// ScanTree measures path and everything below it
+func ScanTree(ctx context.Context, path string) (*DiskNode, error) {
+	return cleaner.ScanTree(ctx, path)
+}
+
+// MeasurePath returns the size of a file or directory in bytes, 0 if it
+// does not exist
+func MeasurePath(path string) (int64, error) {
+	return cleaner.MeasurePath(path)
+}
+
+// ExpandPath expands a leading ~ and environment variables in path
+func ExpandPath(path string) string {
+	return cleaner.ExpandPath(path)
+}
+
+// RemoveWithin removes path if it is below root once symbolic links are
+// resolved, and returns *errors.ErrSafetyViolation otherwise
+func RemoveWithin(root, path string) error {
+	return cleaner.RemoveWithin(root, path)
+}
+
+// ListItems returns the individual entries in the cache of c. Cleaners that
+// do not implement Lister fall back to the targets their plan would remove.
+// It returns *errors.ErrNotSupported if c can do neither.
+func ListItems(ctx context.Context, c Cleaner) ([]CacheItem, error) {
+	if lister, ok := c.(Lister); ok {
+		return lister.Items(ctx)
+	}
+	planner, ok := c.(Planner)
+	if !ok {
+		return nil, errors.NewErrNotSupported("listing entries", c.GetName()+" cannot list its cache")
+	}
+	plan, err := planner.Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var items []CacheItem
+	for _, item := range plan.Items {
+		if item.Remove && item.Size >= 0 {
+			items = append(items, CacheItem{Name: item.Target, Size: item.Size})
+		}
+	}
+	return items, nil
+}
+
+// Item is a cache entry together with the cleaner that owns it
+type Item struct {
+	Cleaner string
+	CacheItem
+}
+
+// Largest returns the n largest entries across the caches of entries,
+// largest first, or all of them if n is not positive. Entries seen by more
+// than one cleaner are attributed to the first. Cleaners that cannot run here
+// or cannot list their cache are left out; other failures are returned.
+func Largest(ctx context.Context, entries []Entry, n int) ([]Item, []error) {
+	var items []Item
+	var errs []error
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		c := e.New()
+		if Available(ctx, c) != nil {
+			continue
+		}
+		found, err := ListItems(ctx, c)
+		var notSupported *errors.ErrNotSupported
+		if stderrors.As(err, &notSupported) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Key, err))
+			continue
+		}
+		for _, item := range found {
+			if !seen[item.Name] {
+				seen[item.Name] = true
+				items = append(items, Item{Cleaner: e.Key, CacheItem: item})
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Size > items[j].Size
+	})
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items, errs
+}
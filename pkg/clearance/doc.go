@@ -0,0 +1,79 @@
+// Package clearance is the public API for embedding clearance's cleaners in
+// other Go programs. The clearance command line tool is built on it.
+//
+// Build a registry of the built-in cleaners, measure them and clean the
+// ones you want:
+//
+//	registry := clearance.NewDefaultRegistry(clearance.RegistryOptions{
+//		Docker: clearance.DefaultDockerOptions(),
+//	})
+//
+//	for _, size := range clearance.Scan(ctx, registry.Entries()) {
+//		fmt.Printf("%s: %s\n", size.Description, size.Status)
+//	}
+//
+//	entry, _ := registry.Lookup("npm")
+//	result := clearance.Execute(ctx, entry.New())
+//	if result.Error != nil {
+//		return result.Error
+//	}
+//	fmt.Printf("freed %s\n", clearance.FormatSize(result.Freed()))
+//
+// Preview what a cleaner would remove without removing anything:
+//
+//	plan, err := clearance.PlanCleaner(ctx, entry.New())
+//	if err != nil {
+//		return err // *errors.ErrNotSupported if the cleaner cannot plan
+//	}
+//	for _, item := range plan.Items {
+//		fmt.Println(item.Remove, item.Target, item.Reason)
+//	}
+//
+// Cleaners report progress as they go, on os.Stdout by default. Send it
+// elsewhere, or drop it, with WithOutput:
+//
+//	ctx = clearance.WithOutput(ctx, io.Discard)
+//
+// Add your own cleaners alongside the built-in ones by implementing Cleaner,
+// and optionally Measurer, Planner, Checker, Elevated, Locator, Sharer,
+// InUseChecker and Lister, or by
+// declaring paths:
+//
+//	registry.Register(clearance.Entry{
+//		Key:         "models",
+//		Description: "ML model downloads",
+//		New: func() clearance.Cleaner {
+//			return clearance.NewCustomCleaner(clearance.CustomOptions{
+//				Name:   "models",
+//				Paths:  []string{"~/.cache/models/*"},
+//				Root:   "~/.cache/models",
+//				MinAge: 30 * 24 * time.Hour,
+//			})
+//		},
+//	})
+//
+// # Compatibility
+//
+// This package follows semantic versioning together with the module. Many
+// of its types are aliases of types in internal packages; the guarantees
+// below cover them exactly as if they were declared here, whatever happens
+// to the internal declarations. Within a major version:
+//
+//   - exported identifiers, including the fields and methods of the aliased
+//     types, are not removed or renamed, and function and method
+//     signatures do not change;
+//   - no methods are added to the Cleaner, Measurer, Planner, Checker,
+//     Elevated, Locator, Sharer, InUseChecker and Lister interfaces; new
+//     capabilities are added as new optional interfaces;
+//   - fields may be added to the option and result structs, so construct
+//     them with field names;
+//   - the set, order and keys of built-in cleaners in NewDefaultRegistry may
+//     grow; look cleaners up by key rather than by position.
+//
+// CI builds an API check that spells out every exported signature, field
+// and interface method set, and runs the package examples, so a change that
+// breaks these guarantees fails the build.
+//
+// Errors are the types in github.com/abdorrahmani/clearance/pkg/errors.
+// Everything under internal/ may change at any time.
+package clearance
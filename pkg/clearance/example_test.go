@@ -0,0 +1,86 @@
+package clearance_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+)
+
+// exampleCache creates a throwaway cache directory with two entries
+func exampleCache() (string, func()) {
+	dir, err := os.MkdirTemp("", "clearance-example")
+	if err != nil {
+		panic(err)
+	}
+	for name, data := range map[string]string{"a.bin": "0123456789", "b.bin": "01234"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			panic(err)
+		}
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func ExampleNewDefaultRegistry() {
+	registry := clearance.NewDefaultRegistry(clearance.RegistryOptions{
+		Docker: clearance.DefaultDockerOptions(),
+	})
+
+	// Look cleaners up by key, positions may change between versions
+	entry, ok := registry.Lookup("npm")
+	fmt.Println(ok, entry.Key, entry.Description, entry.InAll)
+	// Output: true npm npm cache true
+}
+
+func ExamplePlanCleaner() {
+	dir, cleanup := exampleCache()
+	defer cleanup()
+
+	c := clearance.NewCustomCleaner(clearance.CustomOptions{
+		Name:  "example",
+		Paths: []string{filepath.Join(dir, "*")},
+		Root:  dir,
+	})
+	plan, err := clearance.PlanCleaner(context.Background(), c)
+	if err != nil {
+		fmt.Println(err) // *errors.ErrNotSupported if the cleaner cannot plan
+		return
+	}
+	for _, item := range plan.Items {
+		fmt.Println(item.Remove, strings.TrimPrefix(item.Target, dir+string(filepath.Separator)), item.Size)
+	}
+	fmt.Println("reclaimable:", plan.Reclaimable())
+	// Output:
+	// true a.bin 10
+	// true b.bin 5
+	// reclaimable: 15
+}
+
+func ExampleExecute() {
+	dir, cleanup := exampleCache()
+	defer cleanup()
+
+	c := clearance.NewCustomCleaner(clearance.CustomOptions{
+		Name:  "example",
+		Paths: []string{filepath.Join(dir, "*.bin")},
+		Root:  dir,
+	})
+	// The cleaner reports each removed path as it goes, discard that
+	ctx := clearance.WithOutput(context.Background(), io.Discard)
+	result := clearance.Execute(ctx, c)
+	if result.Error != nil {
+		fmt.Println(result.Error)
+		return
+	}
+	fmt.Println("freed", result.Freed(), "bytes")
+
+	left, _ := os.ReadDir(dir)
+	fmt.Println(len(left), "entries left")
+	// Output:
+	// freed 15 bytes
+	// 0 entries left
+}
@@ -1,16 +1,31 @@
 package errors
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrQuit is returned when the user asks to quit. It ends the program
+// without being reported as a failure.
+var ErrQuit = errors.New("quit requested")
 
 // ErrAdminRequired is returned when administrator privileges are required
 type ErrAdminRequired struct {
 	Message string
+	Err     error
 }
 
 func (e *ErrAdminRequired) Error() string {
 	return fmt.Sprintf("administrator privileges required: %s", e.Message)
 }
 
+// Unwrap returns the underlying cause, if any
+func (e *ErrAdminRequired) Unwrap() error {
+	return e.Err
+}
+
 // NewErrAdminRequired creates a new ErrAdminRequired error
 func NewErrAdminRequired(message string) error {
 	return &ErrAdminRequired{
@@ -22,12 +37,18 @@ func NewErrAdminRequired(message string) error {
 type ErrCleanupFailed struct {
 	CleanerName string
 	Message     string
+	Err         error
 }
 
 func (e *ErrCleanupFailed) Error() string {
 	return fmt.Sprintf("cleanup failed for %s: %s", e.CleanerName, e.Message)
 }
 
+// Unwrap returns the underlying cause, if any
+func (e *ErrCleanupFailed) Unwrap() error {
+	return e.Err
+}
+
 // NewErrCleanupFailed creates a new ErrCleanupFailed error
 func NewErrCleanupFailed(cleanerName, message string) error {
 	return &ErrCleanupFailed{
@@ -36,16 +57,31 @@ func NewErrCleanupFailed(cleanerName, message string) error {
 	}
 }
 
+// WrapCleanupFailed creates an ErrCleanupFailed caused by err
+func WrapCleanupFailed(cleanerName string, err error) *ErrCleanupFailed {
+	return &ErrCleanupFailed{
+		CleanerName: cleanerName,
+		Message:     err.Error(),
+		Err:         err,
+	}
+}
+
 // ErrNotSupported is returned when an operation is not supported
 type ErrNotSupported struct {
 	Operation string
 	Reason    string
+	Err       error
 }
 
 func (e *ErrNotSupported) Error() string {
 	return fmt.Sprintf("operation not supported: %s - %s", e.Operation, e.Reason)
 }
 
+// Unwrap returns the underlying cause, if any
+func (e *ErrNotSupported) Unwrap() error {
+	return e.Err
+}
+
 // NewErrNotSupported creates a new ErrNotSupported error
 func NewErrNotSupported(operation, reason string) error {
 	return &ErrNotSupported{
@@ -53,3 +89,131 @@ func NewErrNotSupported(operation, reason string) error {
 		Reason:    reason,
 	}
 }
+
+// ErrSafetyViolation is returned when a cleaner refuses to touch a path or
+// object because it falls outside what it is allowed to remove
+type ErrSafetyViolation struct {
+	Target string
+	Reason string
+	Err    error
+}
+
+func (e *ErrSafetyViolation) Error() string {
+	return fmt.Sprintf("refusing to clean %s: %s", e.Target, e.Reason)
+}
+
+// Unwrap returns the underlying cause, if any
+func (e *ErrSafetyViolation) Unwrap() error {
+	return e.Err
+}
+
+// NewErrSafetyViolation creates a new ErrSafetyViolation error
+func NewErrSafetyViolation(target, reason string) error {
+	return &ErrSafetyViolation{
+		Target: target,
+		Reason: reason,
+	}
+}
+
+// ErrInUse is returned when a cache is being used, by the tool that owns it
+// or another clearance process, and is left alone rather than cleaned
+type ErrInUse struct {
+	CleanerName string
+	Reason      string
+	Err         error
+}
+
+func (e *ErrInUse) Error() string {
+	return fmt.Sprintf("%s cache is in use: %s", e.CleanerName, e.Reason)
+}
+
+// Unwrap returns the underlying cause, if any
+func (e *ErrInUse) Unwrap() error {
+	return e.Err
+}
+
+// NewErrInUse creates a new ErrInUse error
+func NewErrInUse(cleanerName, reason string) error {
+	return &ErrInUse{
+		CleanerName: cleanerName,
+		Reason:      reason,
+	}
+}
+
+// ErrCleanupFailures aggregates the failures of a run of several cleaners
+type ErrCleanupFailures struct {
+	// Failures has one entry per failed cleaner
+	Failures []*ErrCleanupFailed
+	// Total is the number of cleaners that ran
+	Total int
+}
+
+func (e *ErrCleanupFailures) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f.CleanerName, f.Message))
+	}
+	return fmt.Sprintf("%d of %d cleaners failed: %s", len(e.Failures), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per-cleaner failures so errors.Is and errors.As search them
+func (e *ErrCleanupFailures) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// Partial reports whether some cleaners succeeded
+func (e *ErrCleanupFailures) Partial() bool {
+	return len(e.Failures) < e.Total
+}
+
+// Exit codes returned by the clearance command
+const (
+	ExitOK              = 0
+	ExitFailure         = 1
+	ExitPartial         = 2
+	ExitNotSupported    = 3
+	ExitAdminRequired   = 4
+	ExitSafetyViolation = 5
+	ExitInUse           = 6
+	ExitCancelled       = 130
+)
+
+// ExitCode returns the process exit code for err. When err holds several
+// errors the most severe one wins: cancellation, then safety violations,
+// then missing privileges.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var safety *ErrSafetyViolation
+	var admin *ErrAdminRequired
+	var failures *ErrCleanupFailures
+	var inUse *ErrInUse
+	var notSupported *ErrNotSupported
+	switch {
+	case errors.Is(err, ErrQuit):
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitCancelled
+	case errors.As(err, &safety):
+		return ExitSafetyViolation
+	case errors.As(err, &admin):
+		return ExitAdminRequired
+	case errors.As(err, &failures):
+		if failures.Partial() {
+			return ExitPartial
+		}
+		return ExitFailure
+	case errors.As(err, &inUse):
+		return ExitInUse
+	case errors.As(err, &notSupported):
+		return ExitNotSupported
+	default:
+		return ExitFailure
+	}
+}
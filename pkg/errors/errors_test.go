@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	safety := NewErrSafetyViolation("/", "file system root")
+	admin := NewErrAdminRequired("root")
+	inUse := NewErrInUse("npm", "npm is running")
+	notSupported := NewErrNotSupported("winsxs", "Windows only")
+	failed := WrapCleanupFailed("yarn", errors.New("exit status 1"))
+	partial := &ErrCleanupFailures{Failures: []*ErrCleanupFailed{failed}, Total: 2}
+	total := &ErrCleanupFailures{Failures: []*ErrCleanupFailed{failed}, Total: 1}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"quit", ErrQuit, ExitOK},
+		{"wrapped quit", fmt.Errorf("menu: %w", ErrQuit), ExitOK},
+		{"plain error", errors.New("boom"), ExitFailure},
+		{"cancelled", context.Canceled, ExitCancelled},
+		{"safety", safety, ExitSafetyViolation},
+		{"admin", admin, ExitAdminRequired},
+		{"partial failure", partial, ExitPartial},
+		{"total failure", total, ExitFailure},
+		{"in use", inUse, ExitInUse},
+		{"not supported", notSupported, ExitNotSupported},
+		{"cleanup failed", failed, ExitFailure},
+
+		{"wrapped safety", fmt.Errorf("docker: %w", safety), ExitSafetyViolation},
+		{"wrapped admin", fmt.Errorf("docker: %w", admin), ExitAdminRequired},
+		{"wrapped cancellation", WrapCleanupFailed("npm", context.Canceled), ExitCancelled},
+		{"wrapped failures", fmt.Errorf("clean: %w", partial), ExitPartial},
+
+		// The most severe of several errors wins
+		{"cancelled over safety", errors.Join(safety, context.Canceled), ExitCancelled},
+		{"safety over admin", errors.Join(admin, safety), ExitSafetyViolation},
+		{"admin over failures", errors.Join(partial, admin), ExitAdminRequired},
+		{"failures over in use", errors.Join(inUse, total), ExitFailure},
+		{"in use over not supported", errors.Join(notSupported, inUse), ExitInUse},
+		{"not supported over plain error", errors.Join(errors.New("boom"), notSupported), ExitNotSupported},
+		{"all of them", errors.Join(notSupported, inUse, partial, admin, safety, context.Canceled), ExitCancelled},
+		{"safety inside failures", &ErrCleanupFailures{
+			Failures: []*ErrCleanupFailed{failed, WrapCleanupFailed("custom", safety)},
+			Total:    3,
+		}, ExitSafetyViolation},
+		{"nested joins", errors.Join(errors.Join(notSupported, fmt.Errorf("x: %w", admin)), inUse), ExitAdminRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrCleanupFailures(t *testing.T) {
+	inUse := NewErrInUse("npm", "npm is running")
+	npm := WrapCleanupFailed("npm", inUse)
+	yarn := NewErrCleanupFailed("yarn", "exit status 1").(*ErrCleanupFailed)
+
+	tests := []struct {
+		name    string
+		err     *ErrCleanupFailures
+		partial bool
+		message string
+	}{
+		{"one of two", &ErrCleanupFailures{Failures: []*ErrCleanupFailed{yarn}, Total: 2}, true,
+			"1 of 2 cleaners failed: yarn: exit status 1"},
+		{"all", &ErrCleanupFailures{Failures: []*ErrCleanupFailed{npm, yarn}, Total: 2}, false,
+			"2 of 2 cleaners failed: npm: npm cache is in use: npm is running; yarn: exit status 1"},
+		{"none", &ErrCleanupFailures{Total: 1}, true, "0 of 1 cleaners failed: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Partial(); got != tt.partial {
+				t.Errorf("Partial() = %v, want %v", got, tt.partial)
+			}
+			if got := tt.err.Error(); got != tt.message {
+				t.Errorf("Error() = %q, want %q", got, tt.message)
+			}
+			unwrapped := tt.err.Unwrap()
+			if len(unwrapped) != len(tt.err.Failures) {
+				t.Fatalf("Unwrap() returned %d errors, want %d", len(unwrapped), len(tt.err.Failures))
+			}
+			for i, err := range unwrapped {
+				if err != tt.err.Failures[i] {
+					t.Errorf("Unwrap()[%d] = %v, want %v", i, err, tt.err.Failures[i])
+				}
+			}
+		})
+	}
+
+	// errors.Is and errors.As reach through the failures to their causes
+	err := fmt.Errorf("clean: %w", &ErrCleanupFailures{Failures: []*ErrCleanupFailed{npm, yarn}, Total: 3})
+	var failed *ErrCleanupFailed
+	if !errors.As(err, &failed) || failed != npm {
+		t.Errorf("errors.As found %v, want the npm failure", failed)
+	}
+	var found *ErrInUse
+	if !errors.As(err, &found) || found != inUse {
+		t.Errorf("errors.As found %v, want the in use cause", found)
+	}
+	if !errors.Is(err, inUse) || errors.Is(err, ErrQuit) {
+		t.Error("errors.Is does not follow the failures")
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/internal/reporter"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportTop    int
+	reportSort   string
+	reportFormat string
+	reportOutput string
+)
+
+// sizeRows converts the rows of a report for display
+func sizeRows(report *reporter.Report) []ui.SizeRow {
+	rows := make([]ui.SizeRow, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		rows = append(rows, ui.SizeRow{
+			Name:     row.Name,
+			Label:    row.Label,
+			Size:     row.Status,
+			Share:    row.FormatShare(),
+			Volume:   row.Volume,
+			Part:     row.Part != "",
+			Measured: row.Bytes >= 0,
+			Failed:   row.Failed(),
+		})
+	}
+	return rows
+}
+
+// volumeRows converts the file systems of a report for display
+func volumeRows(report *reporter.Report) []ui.VolumeRow {
+	rows := make([]ui.VolumeRow, 0, len(report.Volumes))
+	for _, v := range report.Volumes {
+		rows = append(rows, ui.VolumeRow{
+			Path:  v.Path,
+			Free:  clearance.FormatSize(v.Free),
+			Total: clearance.FormatSize(v.Total),
+			Used:  fmt.Sprintf("%.0f%%", v.UsedPercent()),
+		})
+	}
+	return rows
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show the size of every cache",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reportSort != reporter.SortBySize && reportSort != reporter.SortByName {
+			return fmt.Errorf("invalid --sort %q, expected size or name", reportSort)
+		}
+		switch reportFormat {
+		case reporter.FormatTable, reporter.FormatMarkdown, reporter.FormatHTML, reporter.FormatOpenMetrics:
+		default:
+			return fmt.Errorf("invalid --format %q, expected table, markdown, html or openmetrics", reportFormat)
+		}
+		if reportTop > 0 && reportFormat != reporter.FormatTable {
+			return fmt.Errorf("--top only supports the table format")
+		}
+
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+		switch {
+		case reportTop > 0:
+			showTopItems(cmd, ui.NewUI(), registry)
+		case reportFormat == reporter.FormatTable:
+			showReport(ui.NewUI(), registry)
+		default:
+			return exportReport(cmd.Context(), ui.NewUI(), registry)
+		}
+		return nil
+	},
+}
+
+// exportReport measures every cache and writes the report in reportFormat
+// to reportOutput, or stdout
+func exportReport(ctx context.Context, u *ui.UI, registry *clearance.Registry) error {
+	report := measureReport(u, registry)
+	report.Sort(reportSort)
+
+	var runs []history.Run
+	if reportFormat == reporter.FormatHTML || reportFormat == reporter.FormatOpenMetrics {
+		var err error
+		if runs, err = loadHistory(); err != nil {
+			u.ShowWarning(fmt.Sprintf("Could not read run history: %v", err))
+		}
+	}
+	write := func(w io.Writer) error {
+		switch reportFormat {
+		case reporter.FormatMarkdown:
+			return reporter.WriteMarkdown(w, report)
+		case reporter.FormatOpenMetrics:
+			return reporter.WriteOpenMetrics(w, report, countEntries(ctx, registry), history.Trends(runs))
+		}
+		return reporter.WriteHTML(w, report, runs)
+	}
+
+	if reportOutput == "" {
+		return write(os.Stdout)
+	}
+	return writeFileAtomic(reportOutput, write)
+}
+
+// writeFileAtomic writes path through a temporary file renamed into place,
+// so readers such as the node_exporter textfile collector never see a
+// partial file
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes the file private, reports are meant to be read
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// countEntries returns the number of entries in the cache of every cleaner
+// that can list them and runs here
+func countEntries(ctx context.Context, registry *clearance.Registry) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range registry.Entries() {
+		c := e.New()
+		if clearance.Available(ctx, c) != nil {
+			continue
+		}
+		if items, err := clearance.ListItems(ctx, c); err == nil {
+			counts[e.Key] = len(items)
+		}
+	}
+	return counts
+}
+
+// showTopItems displays the largest individual entries across all caches
+func showTopItems(cmd *cobra.Command, u *ui.UI, registry *clearance.Registry) {
+	items, errs := clearance.Largest(cmd.Context(), registry.Entries(), reportTop)
+	for _, err := range errs {
+		u.ShowWarning(fmt.Sprintf("Could not list %v", err))
+	}
+
+	rows := make([]ui.TopRow, 0, len(items))
+	for _, item := range items {
+		lastUsed := "-"
+		if !item.LastUsed.IsZero() {
+			lastUsed = item.LastUsed.Format("2006-01-02 15:04")
+		}
+		rows = append(rows, ui.TopRow{
+			Size:     clearance.FormatSize(item.Size),
+			LastUsed: lastUsed,
+			Cleaner:  item.Cleaner,
+			Name:     item.Name,
+		})
+	}
+	u.ShowTopItems(rows)
+}
+
+// cleanerStatus returns why c cannot run on this machine, or "" if it can
+func cleanerStatus(ctx context.Context, c clearance.Cleaner) string {
+	err := clearance.CheckPrivileges(clearance.RequiredPrivileges(ctx, c))
+	if err == nil {
+		err = clearance.Available(ctx, c)
+	}
+	var notSupported *errors.ErrNotSupported
+	if stderrors.As(err, &notSupported) {
+		return notSupported.Reason
+	} else if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available cleaners and whether they can run on this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		var rows []ui.ListRow
+		for _, e := range registry.Entries() {
+			rows = append(rows, ui.ListRow{
+				Key:         e.Key,
+				Icon:        e.Icon,
+				Description: e.Description,
+				InAll:       e.InAll,
+				Status:      cleanerStatus(ctx, e.New()),
+			})
+		}
+		ui.NewUI().ShowList(rows)
+		return nil
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportFormat, "format", reporter.FormatTable, "output format: table, markdown, html or openmetrics")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write the exported report to a file instead of stdout")
+	reportCmd.Flags().StringVar(&reportSort, "sort", reporter.SortBySize, "order caches by size or name")
+	reportCmd.Flags().IntVar(&reportTop, "top", 0, "list the N largest cache entries instead of cache sizes")
+	rootCmd.AddCommand(reportCmd, listCmd)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abdorrahmani/clearance/internal/schedule"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleEvery  string
+	scheduleMethod string
+)
+
+// scheduledCommand returns the clean command the scheduler runs: this
+// executable with the profile and configuration file given now
+func scheduledCommand(cleaners []string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate the clearance executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	command := append([]string{exe, "clean"}, cleaners...)
+	if profileName != "" {
+		command = append(command, "--profile", profileName)
+	}
+	if configPath != "" {
+		path, err := filepath.Abs(configPath)
+		if err != nil {
+			return nil, err
+		}
+		command = append(command, "--config", path)
+	}
+	// Scheduled output goes to a log, not a terminal
+	return append(command, "--no-color", "--no-emoji"), nil
+}
+
+// scheduleLogFile returns where scheduled runs log to when the scheduler
+// does not keep their output
+func scheduleLogFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "clearance", "schedule.log"), nil
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run clean periodically with the system scheduler",
+	Long: `Schedule installs a systemd user timer, or a crontab entry where systemd is
+not available, or a scheduled task on Windows, that runs clearance clean
+periodically. Scheduled runs are recorded in the history like any other.`,
+}
+
+var scheduleInstallCmd = &cobra.Command{
+	Use:   "install [cleaner...]",
+	Short: "Install or replace the schedule",
+	Example: `  clearance schedule install --every weekly --profile light
+  clearance schedule install --every daily npm yarn`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+		// Check the cleaners now rather than at the first scheduled run, and
+		// schedule them by key: menu positions change as cleaners are added
+		entries, err := selectCleaners(registry, args)
+		if err != nil {
+			return err
+		}
+		var cleaners []string
+		if len(args) > 0 && !(len(args) == 1 && strings.ToLower(args[0]) == "all") {
+			for _, e := range entries {
+				cleaners = append(cleaners, e.Key)
+			}
+		}
+		command, err := scheduledCommand(cleaners)
+		if err != nil {
+			return err
+		}
+		logFile, err := scheduleLogFile()
+		if err != nil {
+			return err
+		}
+		s := schedule.Schedule{Every: scheduleEvery, Command: command, LogFile: logFile}
+		if err := s.Validate(); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		method, err := schedule.Lookup(ctx, scheduleMethod)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			files, err := method.Files(s)
+			if err != nil {
+				return err
+			}
+			var names []string
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("# %s\n%s\n", name, files[name])
+			}
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+			return err
+		}
+		// Only one schedule is kept, whichever method installed it
+		for _, other := range schedule.Methods() {
+			if other.Name() == method.Name() || !other.Available(ctx) {
+				continue
+			}
+			err := other.Remove(ctx)
+			if stderrors.Is(err, schedule.ErrNotInstalled) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("cannot remove %s schedule: %w", other.Name(), err)
+			}
+			ui.NewUI().ShowInfo(fmt.Sprintf("Removed the %s schedule", other.Name()))
+		}
+		if err := method.Install(ctx, s); err != nil {
+			return fmt.Errorf("cannot install %s schedule: %w", method.Name(), err)
+		}
+		ui.NewUI().ShowSuccess(fmt.Sprintf("Scheduled %s to run %s with %s", strings.Join(command[1:], " "), s.Every, method.Name()))
+		return nil
+	},
+}
+
+var scheduleStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the installed schedule",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		u := ui.NewUI()
+		ctx := cmd.Context()
+		installed := false
+		for _, method := range schedule.Methods() {
+			if scheduleMethod != "auto" && scheduleMethod != method.Name() {
+				continue
+			}
+			if !method.Available(ctx) {
+				continue
+			}
+			status, err := method.Status(ctx)
+			if err != nil {
+				u.ShowWarning(fmt.Sprintf("Cannot read the %s schedule: %v", method.Name(), err))
+				continue
+			}
+			if !status.Installed {
+				continue
+			}
+			installed = true
+			u.ShowInfo(fmt.Sprintf("Scheduled with %s", status.Method))
+			if status.Every != "" {
+				fmt.Printf("  Every: %s\n", status.Every)
+			}
+			if status.Command != "" {
+				fmt.Printf("  Command: %s\n", status.Command)
+			}
+			for _, detail := range status.Details {
+				fmt.Printf("  %s\n", detail)
+			}
+		}
+		if !installed {
+			u.ShowInfo("No schedule installed")
+		}
+		return nil
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the installed schedule",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		u := ui.NewUI()
+		ctx := cmd.Context()
+		removed := false
+		for _, method := range schedule.Methods() {
+			if scheduleMethod != "auto" && scheduleMethod != method.Name() {
+				continue
+			}
+			if !method.Available(ctx) {
+				continue
+			}
+			err := method.Remove(ctx)
+			if stderrors.Is(err, schedule.ErrNotInstalled) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("cannot remove %s schedule: %w", method.Name(), err)
+			}
+			removed = true
+			u.ShowSuccess(fmt.Sprintf("Removed the %s schedule", method.Name()))
+		}
+		if !removed {
+			u.ShowInfo("No schedule installed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	scheduleInstallCmd.Flags().StringVar(&scheduleEvery, "every", schedule.Weekly, "how often to clean: "+strings.Join(schedule.Intervals, ", "))
+	scheduleCmd.PersistentFlags().StringVar(&scheduleMethod, "method", "auto", "scheduler to use: auto, systemd, cron or schtasks")
+	scheduleCmd.AddCommand(scheduleInstallCmd, scheduleStatusCmd, scheduleRemoveCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
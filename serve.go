@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/history"
+	"github.com/abdorrahmani/clearance/internal/reporter"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveMetrics   string
+	serveInterval  time.Duration
+	serveAPI       string
+	serveTokenFile string
+)
+
+// metricsCollector measures the caches periodically and serves the last
+// result, so scrapes never wait for a measurement
+type metricsCollector struct {
+	registry *clearance.Registry
+	mu       sync.Mutex
+	body     []byte
+}
+
+// collect measures every cache and replaces the served metrics. Unlike
+// report it does not record the run in the history.
+func (m *metricsCollector) collect(ctx context.Context) error {
+	report := newReporter(m.registry).GetReport()
+	runs, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := reporter.WriteOpenMetrics(&b, report, countEntries(ctx, m.registry), history.Trends(runs)); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.body = b.Bytes()
+	m.mu.Unlock()
+	return nil
+}
+
+// run collects every interval until ctx is done
+func (m *metricsCollector) run(ctx context.Context, u *ui.UI, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.collect(ctx); err != nil && ctx.Err() == nil {
+			u.ShowWarning(fmt.Sprintf("Could not collect metrics: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ServeHTTP serves the last collected metrics
+func (m *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	body := m.body
+	m.mu.Unlock()
+	if body == nil {
+		http.Error(w, "metrics are being collected, try again shortly", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", reporter.OpenMetricsContentType)
+	_, _ = w.Write(body)
+}
+
+// listen listens on a TCP address, or on a Unix socket for "unix:PATH"
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	// A socket left behind by a server that did not shut down cleanly would
+	// make the listen fail, but one a server still answers on is not taken
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is listening on %s", path)
+		}
+		if !stderrors.Is(err, syscall.ECONNREFUSED) {
+			return nil, err
+		}
+		_ = os.Remove(path)
+	}
+
+	// The socket is created in a directory only the owner can enter and
+	// moved into place once only the owner may connect
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".clearance-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	unixListener := listener.(*net.UnixListener)
+	unixListener.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &socketListener{UnixListener: unixListener, path: path}, nil
+}
+
+// socketListener removes its socket when closed, which the listener itself
+// does not do once the socket was moved
+type socketListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *socketListener) Close() error {
+	err := l.UnixListener.Close()
+	_ = os.Remove(l.path)
+	return err
+}
+
+// apiAddress binds API addresses without a host to localhost, so the API
+// is only reachable from other machines when asked for
+func apiAddress(address string) string {
+	if strings.HasPrefix(address, ":") {
+		return "localhost" + address
+	}
+	return address
+}
+
+// isLoopback reports whether a TCP address only accepts local connections
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveUntilDone serves until ctx is done, then shuts down gracefully,
+// letting requests in flight, e.g. a cancelled clean, finish
+func serveUntilDone(ctx context.Context, server *http.Server, listener net.Listener) error {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); !stderrors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve cache metrics for Prometheus and a JSON API over HTTP",
+	Long: `Serve exposes cache metrics for Prometheus with --metrics, and a JSON API to
+list cleaners, measure caches, plan and clean with --api. An API address
+without a host, e.g. :9718, only accepts local connections; use
+0.0.0.0:9718 to accept others, or unix:PATH for a Unix socket. Every
+request must carry the token from --token-file or $CLEARANCE_API_TOKEN as a
+bearer token.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serveMetrics == "" && serveAPI == "" {
+			return fmt.Errorf("nothing to serve, use --metrics <address>, e.g. --metrics :9717, or --api <address>, e.g. --api :9718")
+		}
+		if serveInterval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		u := ui.NewUI()
+		var servers []func() error
+
+		if serveMetrics != "" {
+			listener, err := net.Listen("tcp", serveMetrics)
+			if err != nil {
+				return err
+			}
+			collector := &metricsCollector{registry: registry}
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", collector)
+			server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+			go collector.run(ctx, u, serveInterval)
+			servers = append(servers, func() error { return serveUntilDone(ctx, server, listener) })
+			u.ShowInfo(fmt.Sprintf("Serving metrics on http://%s/metrics, measuring every %s", listener.Addr(), serveInterval))
+		}
+
+		if serveAPI != "" {
+			token, source, err := apiToken(serveTokenFile)
+			if err != nil {
+				return fmt.Errorf("cannot read the API token: %w", err)
+			}
+			listener, err := listen(apiAddress(serveAPI))
+			if err != nil {
+				return err
+			}
+			api := &apiServer{ctx: ctx, u: u, registry: registry, token: token}
+			server := &http.Server{Handler: api.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+			servers = append(servers, func() error { return serveUntilDone(ctx, server, listener) })
+			if strings.HasPrefix(serveAPI, "unix:") {
+				u.ShowInfo(fmt.Sprintf("Serving the API on %s, token from %s", serveAPI, source))
+			} else {
+				u.ShowInfo(fmt.Sprintf("Serving the API on http://%s/api/v1/, token from %s", listener.Addr(), source))
+				if !isLoopback(apiAddress(serveAPI)) {
+					u.ShowWarning("The API is reachable from other machines; anyone with the token can clean caches")
+				}
+			}
+		}
+
+		// Stop every server when one fails
+		errs := make(chan error, len(servers))
+		for _, serve := range servers {
+			go func() {
+				err := serve()
+				cancel()
+				errs <- err
+			}()
+		}
+		var serveErr error
+		for range servers {
+			serveErr = stderrors.Join(serveErr, <-errs)
+		}
+		if serveErr != nil {
+			return serveErr
+		}
+		// Being stopped with Ctrl+C is how a server normally ends
+		u.ShowInfo("Stopped serving")
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveMetrics, "metrics", "", "address to serve OpenMetrics on, e.g. :9717")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 5*time.Minute, "how often to measure the caches")
+	serveCmd.Flags().StringVar(&serveAPI, "api", "", "address to serve the JSON API on, e.g. :9718 for localhost only, or unix:PATH")
+	serveCmd.Flags().StringVar(&serveTokenFile, "token-file", "", "file holding the API token, created if missing (default api.token in the config directory)")
+	rootCmd.AddCommand(serveCmd)
+}
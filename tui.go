@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/abdorrahmani/clearance/pkg/errors"
+)
+
+// runTUI lets the user pick and run cleaners in the full-screen interface.
+// It returns false if the interface could not be shown.
+func runTUI(ctx context.Context, u *ui.UI, registry *clearance.Registry) (bool, error) {
+	entries := registry.Entries()
+	items := make([]ui.TUIItem, len(entries))
+	for i, e := range entries {
+		items[i] = ui.TUIItem{Key: e.Key, Icon: e.Icon, Description: e.Description, InAll: e.InAll}
+	}
+
+	var mu sync.Mutex
+	sizes := make([]int64, len(entries))
+	// cancelled is set when the run is cancelled from the interface
+	var cancelled error
+	// lockErr is set when another process was cleaning
+	var lockErr error
+	// run is created when cleaning starts, so the audit log is only opened
+	// if something is cleaned
+	var run *cleanupRun
+	hooks := ui.TUIHooks{
+		Measure: func(ctx context.Context, i int) string {
+			size := clearance.MeasureEntry(ctx, entries[i])
+			mu.Lock()
+			sizes[i] = size.Bytes
+			mu.Unlock()
+			return size.Status
+		},
+		Clean: func(ctx context.Context, selected []int, t *ui.TUI) []string {
+			cleanLock, err := lockCleaning()
+			if err != nil {
+				mu.Lock()
+				lockErr = err
+				mu.Unlock()
+				for _, i := range selected {
+					t.Finish(i, ui.ItemSkipped, "skipped: "+err.Error())
+				}
+				return []string{"Nothing cleaned: " + err.Error()}
+			}
+			defer cleanLock.Release()
+
+			run = newCleanupRun()
+			cleaned := 0
+			for _, i := range selected {
+				if ctx.Err() != nil {
+					break
+				}
+				mu.Lock()
+				before := sizes[i]
+				mu.Unlock()
+
+				t.Start(i)
+				stop := watchProgress(ctx, entries[i], before, func(fraction float64) { t.Progress(i, fraction) })
+				outcome := run.clean(ctx, entries[i].New())
+				stop()
+
+				var notSupported *errors.ErrNotSupported
+				switch {
+				case !outcome.Required.IsZero():
+					t.Finish(i, ui.ItemSkipped, fmt.Sprintf("skipped: %s required", outcome.Required))
+				case outcome.InUse != nil:
+					t.Finish(i, ui.ItemSkipped, "in use: "+outcome.InUse.Reason)
+				case stderrors.As(outcome.Skipped, &notSupported):
+					t.Finish(i, ui.ItemSkipped, "skipped: "+notSupported.Reason)
+				case outcome.Failed != nil:
+					t.Finish(i, ui.ItemFailed, outcome.Result.Error.Error())
+				case ctx.Err() != nil:
+					t.Finish(i, ui.ItemFailed, "cancelled")
+				default:
+					cleaned++
+					t.Finish(i, ui.ItemDone, "freed "+clearance.FormatSize(outcome.Result.Freed()))
+				}
+			}
+			run.finish(u)
+			mu.Lock()
+			cancelled = ctx.Err()
+			mu.Unlock()
+
+			summary := fmt.Sprintf("Freed %s", clearance.FormatSize(run.freed()))
+			if ctx.Err() != nil {
+				summary = "Cleanup cancelled. " + summary
+			}
+			return []string{summary, fmt.Sprintf("%d cleaned, %d skipped, %d failed",
+				cleaned, len(run.skipped), len(run.failures))}
+		},
+	}
+
+	title := fmt.Sprintf("Clearance v%s", cfg.GetVersion())
+	if err := ui.RunTUI(ctx, title, items, hooks); err != nil {
+		return false, nil
+	}
+	if lockErr != nil {
+		return true, lockErr
+	}
+	if run == nil || run.total == 0 {
+		return true, ctx.Err()
+	}
+
+	if cancelled == nil {
+		cancelled = ctx.Err()
+	}
+	if cancelled != nil {
+		u.ShowWarning("Cleanup cancelled")
+		return true, cancelled
+	}
+	if len(run.needAdmin) > 0 {
+		u.ShowAdminWarning(run.needAdmin)
+	}
+	u.ShowCleanupComplete(len(run.failures))
+	if freed := run.freed(); freed > 0 {
+		u.ShowSuccess(fmt.Sprintf("Freed %s", clearance.FormatSize(freed)))
+	}
+	return true, run.err(ctx)
+}
+
+// progressInterval is how often watchProgress measures a cache. Measuring
+// reads the whole cache, so it is kept well apart to leave the disk to the
+// cleaner.
+const progressInterval = 5 * time.Second
+
+// watchProgress reports how much of the before bytes of e have been removed
+// by measuring a second instance every progressInterval until stop is
+// called. Progress is reported as unknown when nothing was measured
+// beforehand.
+func watchProgress(ctx context.Context, e clearance.Entry, before int64, report func(float64)) (stop func()) {
+	if before <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c := e.New()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		report(0)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			size, err := clearance.Measure(ctx, c)
+			if err != nil || size < 0 {
+				continue
+			}
+			report(min(max(1-float64(size)/float64(before), 0), 1))
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
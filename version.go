@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/abdorrahmani/clearance/internal/config"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version, commit and build date",
+	// The version is shown even if the configuration file is broken
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfg = config.NewConfig(version, commit, date)
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui.NewUI().ShowVersion(cfg.GetVersion(), cfg.GetCommit(), cfg.GetDate())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
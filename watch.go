@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/abdorrahmani/clearance/internal/reporter"
+	"github.com/abdorrahmani/clearance/internal/ui"
+	"github.com/abdorrahmani/clearance/pkg/clearance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchMinFree  string
+	watchPath     string
+	watchInterval time.Duration
+	watchCooldown time.Duration
+)
+
+// defaultWatchPlan lists the built-in cleaners cheapest to recover from
+// first: temporary files and unused container data before package caches
+// that must be downloaded again, and images and WinSxS last
+var defaultWatchPlan = []string{
+	"wintemp", "winchunks",
+	"docker-containers", "docker-dangling", "docker-buildcache",
+	"yarn", "npm",
+	"docker-images", "winsxs",
+}
+
+// watchPlan resolves the cleaners to run when space is low, in order. With
+// no keys it uses defaultWatchPlan followed by the other cleaners selected
+// by "all" that are not covered by it.
+func watchPlan(registry *clearance.Registry, keys []string) ([]clearance.Entry, error) {
+	if len(keys) > 0 {
+		return selectCleaners(registry, keys)
+	}
+
+	var plan []clearance.Entry
+	covered := make(map[string]bool)
+	for _, key := range defaultWatchPlan {
+		if e, ok := registry.Lookup(key); ok {
+			plan = append(plan, e)
+			covered[e.Key] = true
+			covered[e.Part] = true
+		}
+	}
+	for _, e := range registry.All() {
+		if !covered[e.Key] {
+			plan = append(plan, e)
+		}
+	}
+	return plan, nil
+}
+
+// watcher cleans whenever free space drops below minFree
+type watcher struct {
+	u        *ui.UI
+	log      *log.Logger
+	plan     []clearance.Entry
+	path     string
+	minFree  int64
+	cooldown time.Duration
+	// lastRun is when each cleaner last ran, for the cooldown
+	lastRun map[string]time.Time
+	// low is set while free space is below minFree, waiting while every
+	// cleaner is cooling down
+	low     bool
+	waiting bool
+}
+
+// free returns the free space on the watched file system
+func (w *watcher) free() (int64, error) {
+	volume, err := reporter.VolumeOf(w.path)
+	if err != nil {
+		return 0, err
+	}
+	return volume.Free, nil
+}
+
+// check cleans if free space is low, running cleaners in order until it
+// recovers. Cleaners still cooling down are left out.
+func (w *watcher) check(ctx context.Context) {
+	free, err := w.free()
+	if err != nil {
+		w.log.Printf("Cannot read free space of %s: %v", w.path, err)
+		return
+	}
+	if free >= w.minFree {
+		if w.low {
+			w.log.Printf("Free space recovered: %s", clearance.FormatSize(free))
+			w.low, w.waiting = false, false
+		}
+		return
+	}
+	w.low = true
+
+	var due []clearance.Entry
+	for _, e := range w.plan {
+		if last, ok := w.lastRun[e.Key]; !ok || time.Since(last) >= w.cooldown {
+			due = append(due, e)
+		}
+	}
+	if len(due) == 0 {
+		// Say so once rather than on every check until a cooldown ends
+		if !w.waiting {
+			w.log.Printf("Free space %s is below %s, every cleaner is cooling down for %s",
+				clearance.FormatSize(free), clearance.FormatSize(w.minFree), w.cooldown)
+			w.waiting = true
+		}
+		return
+	}
+	w.waiting = false
+	w.log.Printf("Free space %s is below %s", clearance.FormatSize(free), clearance.FormatSize(w.minFree))
+
+	cleanLock, err := lockCleaning()
+	if stderrors.Is(err, errCleanRunning) {
+		w.log.Printf("Deferring: %v", err)
+		return
+	}
+	if err != nil {
+		w.log.Printf("Cannot take the clean lock: %v", err)
+		return
+	}
+	defer cleanLock.Release()
+
+	run := newCleanupRun()
+	for _, e := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		w.lastRun[e.Key] = time.Now()
+		w.log.Printf("Running %s", e.Key)
+		outcome := run.clean(ctx, e.New())
+		switch {
+		case outcome.InUse != nil:
+			// Not cooling down, so it is tried again at the next check
+			delete(w.lastRun, e.Key)
+			w.log.Printf("Deferring %s: in use, %s", e.Key, outcome.InUse.Reason)
+		case outcome.Skipped != nil:
+			w.log.Printf("Skipped %s: %v", e.Key, outcome.Skipped)
+		case outcome.Failed != nil:
+			w.log.Printf("%s failed: %v", e.Key, outcome.Result.Error)
+		default:
+			w.log.Printf("%s freed %s", e.Key, clearance.FormatSize(outcome.Result.Freed()))
+		}
+
+		if free, err = w.free(); err == nil && free >= w.minFree {
+			break
+		}
+	}
+	run.finish(w.u)
+
+	switch {
+	case ctx.Err() != nil:
+	case err != nil:
+		w.log.Printf("Cannot read free space of %s: %v", w.path, err)
+	case free >= w.minFree:
+		w.log.Printf("Free space recovered: %s", clearance.FormatSize(free))
+		w.low = false
+	default:
+		w.log.Printf("Free space still %s after running %d cleaner(s)", clearance.FormatSize(free), run.total)
+	}
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [cleaner...]",
+	Short: "Clean automatically whenever free disk space drops below a threshold",
+	Long: `Watch polls the free space of the file system holding --path and, when it
+drops below --min-free, runs the given cleaners in order until enough space
+is free. Without cleaners, temporary files and unused container data are
+cleaned before package caches and images. A cleaner that ran is left alone
+for --cooldown. Watch never cleans while another clearance clean runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if watchMinFree == "" {
+			return fmt.Errorf("--min-free is required, e.g. --min-free 15GB")
+		}
+		minFree, err := clearance.ParseSize(watchMinFree)
+		if err != nil {
+			return fmt.Errorf("invalid --min-free: %w", err)
+		}
+		if watchInterval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+		if len(args) == 0 {
+			args = cfg.Settings.Watch.Cleaners
+		}
+
+		registry, err := newRegistry()
+		if err != nil {
+			return err
+		}
+		plan, err := watchPlan(registry, args)
+		if err != nil {
+			return err
+		}
+		path := watchPath
+		if path == "" {
+			if path, err = os.UserHomeDir(); err != nil {
+				return err
+			}
+		}
+
+		w := &watcher{
+			u:        ui.NewUI(),
+			log:      log.New(os.Stdout, "", log.LstdFlags),
+			plan:     plan,
+			path:     path,
+			minFree:  minFree,
+			cooldown: watchCooldown,
+			lastRun:  make(map[string]time.Time),
+		}
+		if _, err := w.free(); err != nil {
+			return fmt.Errorf("cannot read free space of %s: %w", path, err)
+		}
+
+		var keys []string
+		for _, e := range plan {
+			keys = append(keys, e.Key)
+		}
+		w.log.Printf("Watching %s every %s, keeping %s free with %v", path, watchInterval, clearance.FormatSize(minFree), keys)
+
+		ctx := cmd.Context()
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			w.check(ctx)
+			select {
+			case <-ctx.Done():
+				// Being stopped is how a watch normally ends
+				w.log.Printf("Stopped watching")
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchMinFree, "min-free", "", "free space to keep, e.g. 15GB")
+	watchCmd.Flags().StringVar(&watchPath, "path", "", "a path on the file system to watch (default the home directory)")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Minute, "how often to check free space")
+	watchCmd.Flags().DurationVar(&watchCooldown, "cooldown", 30*time.Minute, "how long to leave a cleaner alone after it ran")
+	rootCmd.AddCommand(watchCmd)
+}